@@ -0,0 +1,262 @@
+package gobitcoinclilight
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+const (
+	opReturn      = 0x6a
+	opDup         = 0x76
+	opEqual       = 0x87
+	opEqualVerify = 0x88
+	opHash160     = 0xa9
+	opCheckSig    = 0xac
+)
+
+// base58 versions of P2PKH/P2SH addresses: mainnet, then testnet/signet/regtest
+var (
+	p2pkhVersions = []byte{0x00, 0x6f}
+	p2shVersions  = []byte{0x05, 0xc4}
+	bech32HRPs    = []string{"bc", "tb", "bcrt"}
+)
+
+// AddressScriptPubKey decodes a P2PKH, P2SH or segwit (bech32/bech32m)
+// address of any network into the scriptPubKey it pays to.
+func AddressScriptPubKey(address string) (scriptPubKey []byte, err error) {
+
+	hrp, _, found := strings.Cut(strings.ToLower(address), "1")
+	if found {
+		for _, bech32HRP := range bech32HRPs {
+			if hrp == bech32HRP {
+				return segwitScriptPubKey(address)
+			}
+		}
+	}
+
+	payload, err := base58CheckDecode(address)
+	if err != nil {
+		err = fmt.Errorf("address[%s]: %v", address, err)
+		return
+	}
+	if len(payload) != 21 {
+		err = fmt.Errorf("address[%s]: incorrect length %d", address, len(payload))
+		return
+	}
+	switch {
+	case bytes.IndexByte(p2pkhVersions, payload[0]) >= 0:
+		scriptPubKey = append([]byte{opDup, opHash160, 20}, payload[1:]...)
+		scriptPubKey = append(scriptPubKey, opEqualVerify, opCheckSig)
+	case bytes.IndexByte(p2shVersions, payload[0]) >= 0:
+		scriptPubKey = append([]byte{opHash160, 20}, payload[1:]...)
+		scriptPubKey = append(scriptPubKey, opEqual)
+	default:
+		err = fmt.Errorf("address[%s]: unknown version %d", address, payload[0])
+	}
+	return
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func base58CheckDecode(encoded string) (payload []byte, err error) {
+
+	decoded := make([]byte, 0, len(encoded))
+	for _, char := range encoded {
+		carry := strings.IndexRune(base58Alphabet, char)
+		if carry < 0 {
+			err = fmt.Errorf("incorrect base58 character %q", char)
+			return
+		}
+		for i := range decoded {
+			carry += int(decoded[i]) * 58
+			decoded[i] = byte(carry)
+			carry >>= 8
+		}
+		for ; carry > 0; carry >>= 8 {
+			decoded = append(decoded, byte(carry))
+		}
+	}
+	for i := 0; i < len(encoded) && encoded[i] == '1'; i++ {
+		decoded = append(decoded, 0)
+	}
+	for i, j := 0, len(decoded)-1; i < j; i, j = i+1, j-1 {
+		decoded[i], decoded[j] = decoded[j], decoded[i]
+	}
+
+	if len(decoded) < 4 {
+		err = fmt.Errorf("base58 string too short")
+		return
+	}
+	payload, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	if !bytes.Equal(doubleSha256(payload)[:4], checksum) {
+		err = fmt.Errorf("incorrect base58 checksum")
+		return
+	}
+	return
+}
+
+func doubleSha256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+const (
+	bech32Charset   = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32Const     = 1
+	bech32mConst    = 0x2bc830a3
+	bech32MaxLength = 90
+)
+
+func bech32Polymod(values []byte) (checksum uint32) {
+
+	generators := []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	checksum = 1
+	for _, value := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(value)
+		for i, generator := range generators {
+			if (top>>uint(i))&1 == 1 {
+				checksum ^= generator
+			}
+		}
+	}
+	return
+}
+
+// bech32Decode returns the data part (5 bit groups, without checksum) and the
+// checksum constant it verifies with: bech32Const or bech32mConst.
+func bech32Decode(encoded string) (hrp string, data []byte, constant uint32, err error) {
+
+	if len(encoded) > bech32MaxLength || (strings.ToLower(encoded) != encoded && strings.ToUpper(encoded) != encoded) {
+		err = fmt.Errorf("incorrect bech32 string")
+		return
+	}
+	encoded = strings.ToLower(encoded)
+	separator := strings.LastIndexByte(encoded, '1')
+	if separator < 1 || separator+7 > len(encoded) {
+		err = fmt.Errorf("incorrect bech32 separator position")
+		return
+	}
+	hrp = encoded[:separator]
+
+	for _, char := range encoded[separator+1:] {
+		value := strings.IndexRune(bech32Charset, char)
+		if value < 0 {
+			err = fmt.Errorf("incorrect bech32 character %q", char)
+			return
+		}
+		data = append(data, byte(value))
+	}
+
+	values := make([]byte, 0, len(hrp)*2+1+len(data))
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]>>5)
+	}
+	values = append(values, 0)
+	for i := 0; i < len(hrp); i++ {
+		values = append(values, hrp[i]&31)
+	}
+	values = append(values, data...)
+
+	constant = bech32Polymod(values)
+	if constant != bech32Const && constant != bech32mConst {
+		err = fmt.Errorf("incorrect bech32 checksum")
+		return
+	}
+	data = data[:len(data)-6]
+	return
+}
+
+func convertBits(data []byte, fromBits uint, toBits uint, pad bool) (converted []byte, err error) {
+
+	acc, bits := uint32(0), uint(0)
+	maxValue := uint32(1)<<toBits - 1
+	for _, value := range data {
+		acc = acc<<fromBits | uint32(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			converted = append(converted, byte(acc>>bits&maxValue))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			converted = append(converted, byte(acc<<(toBits-bits)&maxValue))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxValue != 0 {
+		err = fmt.Errorf("incorrect padding")
+	}
+	return
+}
+
+func segwitScriptPubKey(address string) (scriptPubKey []byte, err error) {
+
+	_, data, constant, err := bech32Decode(address)
+	if err != nil {
+		err = fmt.Errorf("address[%s]: %v", address, err)
+		return
+	}
+	if len(data) < 1 || data[0] > 16 {
+		err = fmt.Errorf("address[%s]: incorrect witness version", address)
+		return
+	}
+	witnessVersion := data[0]
+	if (witnessVersion == 0) != (constant == bech32Const) {
+		err = fmt.Errorf("address[%s]: witness version %d with the wrong checksum", address, witnessVersion)
+		return
+	}
+
+	program, err := convertBits(data[1:], 5, 8, false)
+	if err != nil {
+		err = fmt.Errorf("address[%s]: %v", address, err)
+		return
+	}
+	if len(program) < 2 || len(program) > 40 || (witnessVersion == 0 && len(program) != 20 && len(program) != 32) {
+		err = fmt.Errorf("address[%s]: incorrect witness program length %d", address, len(program))
+		return
+	}
+
+	opVersion := witnessVersion
+	if witnessVersion > 0 {
+		opVersion = 0x50 + witnessVersion
+	}
+	scriptPubKey = append([]byte{opVersion, byte(len(program))}, program...)
+	return
+}
+
+// script types, named as in the "type" of a decoded scriptPubKey
+const (
+	ScriptTypeP2PK     = "pubkey"
+	ScriptTypeP2PKH    = "pubkeyhash"
+	ScriptTypeP2SH     = "scripthash"
+	ScriptTypeP2WPKH   = "witness_v0_keyhash"
+	ScriptTypeP2WSH    = "witness_v0_scripthash"
+	ScriptTypeP2TR     = "witness_v1_taproot"
+	ScriptTypeNullData = "nulldata"
+	ScriptTypeUnknown  = "nonstandard"
+)
+
+// ScriptTypeOf classifies the standard scriptPubKeys a wallet pays to.
+func ScriptTypeOf(scriptPubKey []byte) string {
+
+	switch {
+	case len(scriptPubKey) == 25 && scriptPubKey[0] == opDup && scriptPubKey[1] == opHash160 && scriptPubKey[2] == 20 && scriptPubKey[23] == opEqualVerify && scriptPubKey[24] == opCheckSig:
+		return ScriptTypeP2PKH
+	case len(scriptPubKey) == 23 && scriptPubKey[0] == opHash160 && scriptPubKey[1] == 20 && scriptPubKey[22] == opEqual:
+		return ScriptTypeP2SH
+	case len(scriptPubKey) == 22 && scriptPubKey[0] == 0 && scriptPubKey[1] == 20:
+		return ScriptTypeP2WPKH
+	case len(scriptPubKey) == 34 && scriptPubKey[0] == 0 && scriptPubKey[1] == 32:
+		return ScriptTypeP2WSH
+	case len(scriptPubKey) == 34 && scriptPubKey[0] == 0x51 && scriptPubKey[1] == 32:
+		return ScriptTypeP2TR
+	case (len(scriptPubKey) == 35 || len(scriptPubKey) == 67) && int(scriptPubKey[0]) == len(scriptPubKey)-2 && scriptPubKey[len(scriptPubKey)-1] == opCheckSig:
+		return ScriptTypeP2PK
+	case len(scriptPubKey) > 0 && scriptPubKey[0] == opReturn:
+		return ScriptTypeNullData
+	}
+	return ScriptTypeUnknown
+}
@@ -0,0 +1,58 @@
+package gobitcoinclilight
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestAddressScriptPubKey(t *testing.T) {
+
+	addresses := map[string]string{
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa":                             "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac",
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy":                             "a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87",
+		"tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh":                     "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+		"TB1Q8YU29C59HLMEM3HED28F49K4F3KWWKRV4SMGKH":                     "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+		"bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr": "5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c",
+	}
+	for address, expected := range addresses {
+		scriptPubKey, err := AddressScriptPubKey(address)
+		if err != nil || hex.EncodeToString(scriptPubKey) != expected {
+			t.Fatalf("%s: unexpected scriptPubKey %x: %v", address, scriptPubKey, err)
+		}
+	}
+
+	incorrects := []string{
+		"",
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", // checksum
+		"tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkj",                     // checksum
+		"tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4SMGKH",                     // mixed case
+		"bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrc0", // checksum
+		"0OIl",
+	}
+	for _, address := range incorrects {
+		if scriptPubKey, err := AddressScriptPubKey(address); err == nil {
+			t.Fatalf("%s: expected an error, got %x", address, scriptPubKey)
+		}
+	}
+}
+
+func TestScriptTypeOf(t *testing.T) {
+
+	scriptTypes := map[string]string{
+		"76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac":                     ScriptTypeP2PKH,
+		"a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87":                         ScriptTypeP2SH,
+		"00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c":                           ScriptTypeP2WPKH,
+		"00203938a2e285bff79dc6f96a8e9a96d54c6ce7586c3938a2e285bff79dc6f96a8e":   ScriptTypeP2WSH,
+		"5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c":   ScriptTypeP2TR,
+		"210307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52ac": ScriptTypeP2PK,
+		"6a0548454c4c4f": ScriptTypeNullData,
+		"":               ScriptTypeUnknown,
+		"0014":           ScriptTypeUnknown,
+	}
+	for scriptHex, expected := range scriptTypes {
+		scriptPubKey, _ := hex.DecodeString(scriptHex)
+		if scriptType := ScriptTypeOf(scriptPubKey); scriptType != expected {
+			t.Fatalf("%s: unexpected type %s instead of %s", scriptHex, scriptType, expected)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package gobitcoinclilight
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is a number of satoshis. In JSON it is the decimal BTC value bitcoind
+// uses (e.g. 0.00015000), converted without going through float64.
+type Amount int64
+
+const (
+	Satoshi      Amount = 1
+	MilliBitcoin Amount = 100000
+	Bitcoin      Amount = 100000000
+	MaxMoney     Amount = 21000000 * Bitcoin
+)
+
+type AmountUnit int
+
+const (
+	AmountBTC AmountUnit = iota
+	AmountMilliBTC
+	AmountSatoshi
+)
+
+func (unit AmountUnit) String() string {
+	switch unit {
+	case AmountMilliBTC:
+		return "mBTC"
+	case AmountSatoshi:
+		return "sat"
+	default:
+		return "BTC"
+	}
+}
+
+func (unit AmountUnit) decimals() int {
+	switch unit {
+	case AmountMilliBTC:
+		return 5
+	case AmountSatoshi:
+		return 0
+	default:
+		return 8
+	}
+}
+
+// NewAmountFromBTC rounds btc to the nearest satoshi.
+func NewAmountFromBTC(btc float64) (amount Amount, err error) {
+
+	if math.IsNaN(btc) || math.IsInf(btc, 0) {
+		err = fmt.Errorf("incorrect btc[%v]", btc)
+		return
+	}
+	satoshis := math.Round(btc * float64(Bitcoin))
+	if math.Abs(satoshis) > float64(MaxMoney) {
+		err = fmt.Errorf("btc[%v] out of range", btc)
+		return
+	}
+	amount = Amount(satoshis)
+	return
+}
+
+// ParseAmount parses a decimal BTC value like "0.00015" or "-1.5" exactly;
+// more than 8 decimals is an error unless the extra digits are zeros.
+func ParseAmount(btc string) (amount Amount, err error) {
+	return parseAmount(btc, AmountBTC)
+}
+
+// ParseAmountUnit is ParseAmount for a value in unit.
+func ParseAmountUnit(value string, unit AmountUnit) (amount Amount, err error) {
+	return parseAmount(value, unit)
+}
+
+func parseAmount(value string, unit AmountUnit) (amount Amount, err error) {
+
+	digits := value
+	negative := false
+	if strings.HasPrefix(digits, "-") {
+		negative = true
+		digits = digits[1:]
+	}
+
+	integerPart, fractionPart, hasPoint := strings.Cut(digits, ".")
+	if integerPart == "" && (!hasPoint || fractionPart == "") {
+		err = fmt.Errorf("incorrect amount[%s]", value)
+		return
+	}
+	if strings.IndexFunc(integerPart+fractionPart, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		err = fmt.Errorf("incorrect amount[%s]", value)
+		return
+	}
+
+	decimals := unit.decimals()
+	if len(fractionPart) > decimals {
+		if strings.Trim(fractionPart[decimals:], "0") != "" {
+			err = fmt.Errorf("amount[%s] is more precise than a satoshi", value)
+			return
+		}
+		fractionPart = fractionPart[:decimals]
+	}
+	fractionPart += strings.Repeat("0", decimals-len(fractionPart))
+
+	integerPart = strings.TrimLeft(integerPart, "0")
+	if len(integerPart) > 16 {
+		err = fmt.Errorf("amount[%s] out of range", value)
+		return
+	}
+	satoshis, err := strconv.ParseInt("0"+integerPart+fractionPart, 10, 64)
+	if err != nil || Amount(satoshis) > MaxMoney {
+		err = fmt.Errorf("amount[%s] out of range", value)
+		return
+	}
+
+	amount = Amount(satoshis)
+	if negative {
+		amount = -amount
+	}
+	return
+}
+
+func (amount Amount) BTC() float64 {
+	return float64(amount) / float64(Bitcoin)
+}
+
+func (amount Amount) Satoshis() int64 {
+	return int64(amount)
+}
+
+func (amount Amount) Add(other Amount) Amount {
+	return amount + other
+}
+
+func (amount Amount) Sub(other Amount) Amount {
+	return amount - other
+}
+
+func (amount Amount) Mul(n int64) Amount {
+	return amount * Amount(n)
+}
+
+// MulF64 scales amount by f, rounding to the nearest satoshi.
+func (amount Amount) MulF64(f float64) Amount {
+	return Amount(math.Round(float64(amount) * f))
+}
+
+// Div splits amount in n parts, returning the part and the remainder.
+func (amount Amount) Div(n int64) (part Amount, remainder Amount) {
+	return amount / Amount(n), amount % Amount(n)
+}
+
+func (amount Amount) Abs() Amount {
+	if amount < 0 {
+		return -amount
+	}
+	return amount
+}
+
+// IsValid reports whether amount is within ±MaxMoney.
+func (amount Amount) IsValid() bool {
+	return amount >= -MaxMoney && amount <= MaxMoney
+}
+
+func SumAmounts(amounts ...Amount) (sum Amount) {
+	for _, amount := range amounts {
+		sum += amount
+	}
+	return
+}
+
+// FormatFixed formats amount in unit with all its decimals, e.g. "0.00015000".
+func (amount Amount) FormatFixed(unit AmountUnit) string {
+
+	decimals := unit.decimals()
+	sign := ""
+	satoshis := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		satoshis = uint64(-amount)
+	}
+	if decimals == 0 {
+		return sign + strconv.FormatUint(satoshis, 10)
+	}
+
+	scale := uint64(math.Pow10(decimals))
+	return fmt.Sprintf("%s%d.%0*d", sign, satoshis/scale, decimals, satoshis%scale)
+}
+
+// Format formats amount in unit without trailing zeros, e.g. "0.00015 BTC".
+func (amount Amount) Format(unit AmountUnit) string {
+
+	value := amount.FormatFixed(unit)
+	if strings.Contains(value, ".") {
+		value = strings.TrimRight(strings.TrimRight(value, "0"), ".")
+	}
+	return value + " " + unit.String()
+}
+
+func (amount Amount) String() string {
+	return amount.Format(AmountBTC)
+}
+
+func (amount Amount) MarshalJSON() ([]byte, error) {
+	return []byte(amount.FormatFixed(AmountBTC)), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a string holding one, like the
+// amount arguments of bitcoind.
+func (amount *Amount) UnmarshalJSON(data []byte) (err error) {
+
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return
+	}
+	value := strings.Trim(string(data), `"`)
+
+	// bitcoind never sends exponents, but be lenient with other producers
+	if strings.ContainsAny(value, "eE") {
+		value, err = expandExponent(value)
+		if err != nil {
+			return
+		}
+	}
+
+	*amount, err = parseAmount(value, AmountBTC)
+	return
+}
+
+// expandExponent rewrites e.g. "1.5e-5" as "0.000015".
+func expandExponent(value string) (expanded string, err error) {
+
+	mantissa, exponentText, _ := strings.Cut(strings.ToLower(value), "e")
+	exponent, err := strconv.Atoi(strings.TrimPrefix(exponentText, "+"))
+	if err != nil || exponent < -32 || exponent > 32 {
+		err = fmt.Errorf("incorrect amount[%s]", value)
+		return
+	}
+
+	sign := ""
+	if strings.HasPrefix(mantissa, "-") {
+		sign = "-"
+		mantissa = mantissa[1:]
+	}
+	integerPart, fractionPart, _ := strings.Cut(mantissa, ".")
+	digits := integerPart + fractionPart
+	point := len(integerPart) + exponent
+	switch {
+	case point <= 0:
+		expanded = sign + "0." + strings.Repeat("0", -point) + digits
+	case point >= len(digits):
+		expanded = sign + digits + strings.Repeat("0", point-len(digits))
+	default:
+		expanded = sign + digits[:point] + "." + digits[point:]
+	}
+	return
+}
@@ -0,0 +1,94 @@
+package gobitcoinclilight
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAmountJSON(t *testing.T) {
+
+	type amounts struct {
+		Values []Amount `json:"values"`
+	}
+	result := amounts{}
+	err := json.Unmarshal([]byte(`{"values": [0.00015000, 0.1, "0.2", 20999999.97690000, -0.00000001, 1e-8, 1.5E+1, 0]}`), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Amount{15000, 10000000, 20000000, 2099999997690000, -1, 1, 15 * Bitcoin, 0}
+	for i, amount := range expected {
+		if result.Values[i] != amount {
+			t.Fatalf("values[%d]: expected %d, got %d", i, amount, result.Values[i])
+		}
+	}
+
+	// 0.1 + 0.2 drifts in float64, not in satoshis
+	if result.Values[1]+result.Values[2] != 30000000 {
+		t.Fatalf("unexpected sum %d", result.Values[1]+result.Values[2])
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(jsonBytes) != `{"values":[0.00015000,0.10000000,0.20000000,20999999.97690000,-0.00000001,0.00000001,15.00000000,0.00000000]}` {
+		t.Fatalf("unexpected json %s", jsonBytes)
+	}
+
+	for _, incorrect := range []string{`0.000000001`, `"abc"`, `21000000.00000001`, `1.2.3`, `""`, `-`, `1e40`} {
+		amount := Amount(0)
+		if err = json.Unmarshal([]byte(incorrect), &amount); err == nil {
+			t.Fatalf("expected an error for %s, got %d", incorrect, amount)
+		}
+	}
+}
+
+func TestAmount(t *testing.T) {
+
+	amount, err := ParseAmount("0.00024")
+	if err != nil || amount != 24000 {
+		t.Fatalf("unexpected amount %d: %v", amount, err)
+	}
+	if amount, err = ParseAmountUnit("1.5", AmountMilliBTC); err != nil || amount != 150000 {
+		t.Fatalf("unexpected amount %d: %v", amount, err)
+	}
+	if _, err = ParseAmountUnit("1.5", AmountSatoshi); err == nil {
+		t.Fatalf("expected an error for a fraction of a satoshi")
+	}
+	if amount, err = NewAmountFromBTC(0.1 + 0.2); err != nil || amount != 30000000 {
+		t.Fatalf("unexpected amount %d: %v", amount, err)
+	}
+
+	formats := []struct {
+		amount   Amount
+		unit     AmountUnit
+		expected string
+	}{
+		{15000, AmountBTC, "0.00015 BTC"},
+		{15000, AmountMilliBTC, "0.15 mBTC"},
+		{15000, AmountSatoshi, "15000 sat"},
+		{-2 * Bitcoin, AmountBTC, "-2 BTC"},
+		{0, AmountBTC, "0 BTC"},
+	}
+	for _, format := range formats {
+		if formatted := format.amount.Format(format.unit); formatted != format.expected {
+			t.Fatalf("expected %s, got %s", format.expected, formatted)
+		}
+	}
+	if fixed := Amount(15000).FormatFixed(AmountBTC); fixed != "0.00015000" {
+		t.Fatalf("unexpected fixed format %s", fixed)
+	}
+
+	if sum := SumAmounts(15000, 10000).Sub(1000).Add(1).Mul(2); sum != 48002 {
+		t.Fatalf("unexpected sum %d", sum)
+	}
+	if part, remainder := Amount(10001).Div(3); part != 3333 || remainder != 2 {
+		t.Fatalf("unexpected division %d, %d", part, remainder)
+	}
+	if fee := Amount(100000).MulF64(0.015); fee != 1500 {
+		t.Fatalf("unexpected fee %d", fee)
+	}
+	if (MaxMoney + 1).IsValid() || !(-MaxMoney).IsValid() || Amount(-5).Abs() != 5 {
+		t.Fatalf("unexpected validity")
+	}
+}
@@ -0,0 +1,128 @@
+package gobitcoinclilight
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// RpcAuth supplies the basic auth credentials of every request. When bitcoind
+// answers 401, Refresh is called and the request is retried once if it reports
+// that the credentials changed.
+type RpcAuth interface {
+	Credentials() (rpcUser string, rpcPW string, err error)
+	Refresh() (refreshed bool, err error)
+}
+
+// StaticAuth is a fixed rpcuser/rpcpassword (or an rpcauth= user) pair.
+type StaticAuth struct {
+	RpcUser string
+	RpcPW   string
+}
+
+func (staticAuth StaticAuth) Credentials() (rpcUser string, rpcPW string, err error) {
+	return staticAuth.RpcUser, staticAuth.RpcPW, nil
+}
+
+func (staticAuth StaticAuth) Refresh() (refreshed bool, err error) {
+	return false, nil
+}
+
+// CookieAuth reads bitcoind's ".cookie" file ("__cookie__:<secret>"), which is
+// rewritten with a new secret every time the node restarts.
+type CookieAuth struct {
+	CookiePath string
+
+	mutex   sync.Mutex
+	rpcUser string
+	rpcPW   string
+}
+
+func NewCookieAuth(cookiePath string) (cookieAuth *CookieAuth) {
+	cookieAuth = &CookieAuth{CookiePath: cookiePath}
+	return
+}
+
+func (cookieAuth *CookieAuth) Credentials() (rpcUser string, rpcPW string, err error) {
+
+	cookieAuth.mutex.Lock()
+	defer cookieAuth.mutex.Unlock()
+
+	if cookieAuth.rpcUser == "" {
+		_, err = cookieAuth.read()
+		if err != nil {
+			return
+		}
+	}
+	rpcUser = cookieAuth.rpcUser
+	rpcPW = cookieAuth.rpcPW
+	return
+}
+
+func (cookieAuth *CookieAuth) Refresh() (refreshed bool, err error) {
+
+	cookieAuth.mutex.Lock()
+	defer cookieAuth.mutex.Unlock()
+
+	return cookieAuth.read()
+}
+
+func (cookieAuth *CookieAuth) read() (changed bool, err error) {
+
+	cookieBytes, err := os.ReadFile(cookieAuth.CookiePath)
+	if err != nil {
+		err = fmt.Errorf("@os.ReadFile(cookieAuth.CookiePath): %w", err)
+		return
+	}
+
+	rpcUser, rpcPW, found := strings.Cut(strings.TrimSpace(string(cookieBytes)), ":")
+	if !found || rpcUser == "" {
+		err = fmt.Errorf("incorrect cookie file[%s]", cookieAuth.CookiePath)
+		return
+	}
+
+	changed = rpcUser != cookieAuth.rpcUser || rpcPW != cookieAuth.rpcPW
+	cookieAuth.rpcUser = rpcUser
+	cookieAuth.rpcPW = rpcPW
+	return
+}
+
+func WithAuth(rpcAuth RpcAuth) ClientOption {
+	return func(config *clientConfig) (err error) {
+		config.bitcoinRpc.RpcAuth = rpcAuth
+		return
+	}
+}
+
+func WithCookieFile(cookiePath string) ClientOption {
+	return WithAuth(NewCookieAuth(cookiePath))
+}
+
+// GenerateRpcAuth returns the "rpcauth=<user>:<salt>$<hmac>" line for bitcoin.conf,
+// as share/rpcauth/rpcauth.py does, so the node never stores rpcPW in clear text.
+func GenerateRpcAuth(rpcUser string, rpcPW string) (rpcAuthLine string, err error) {
+
+	if rpcUser == "" || rpcPW == "" {
+		err = fmt.Errorf("rpcUser and rpcPW are required")
+		return
+	}
+
+	saltBytes := make([]byte, 16)
+	_, err = rand.Read(saltBytes)
+	if err != nil {
+		err = fmt.Errorf("@rand.Read(saltBytes): %w", err)
+		return
+	}
+	salt := hex.EncodeToString(saltBytes)
+
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(rpcPW))
+
+	rpcAuthLine = fmt.Sprintf("rpcauth=%s:%s$%s", rpcUser, salt, hex.EncodeToString(mac.Sum(nil)))
+	return
+}
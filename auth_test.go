@@ -0,0 +1,74 @@
+package gobitcoinclilight
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCookieAuth(t *testing.T) {
+
+	secret := "0a1b2c3d"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pw, _ := r.BasicAuth()
+		if user != "__cookie__" || pw != secret {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"result":2344981,"error":null,"id":"GoBitcoinCliLight"}`)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	cookiePath := filepath.Join(t.TempDir(), ".cookie")
+	if err := os.WriteFile(cookiePath, []byte("__cookie__:"+secret), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	bitcoinRpc, err := NewClient(WithHost(serverURL.Hostname(), serverURL.Port()), WithCookieFile(cookiePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = bitcoinRpc.GetBlockCount(); err != nil {
+		t.Fatal(err)
+	}
+
+	// node restart: new secret on disk, the client re-reads it after the 401
+	secret = "4e5f6a7b"
+	if err = os.WriteFile(cookiePath, []byte("__cookie__:"+secret), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = bitcoinRpc.GetBlockCount(); err != nil {
+		t.Fatalf("expected the rotated cookie to be picked up, got %v", err)
+	}
+
+	secret = "unknown"
+	if _, err = bitcoinRpc.GetBlockCount(); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestGenerateRpcAuth(t *testing.T) {
+
+	rpcAuthLine, err := GenerateRpcAuth("ideajoo", "ideajoo123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userSalt, hash, _ := strings.Cut(strings.TrimPrefix(rpcAuthLine, "rpcauth="), "$")
+	user, salt, _ := strings.Cut(userSalt, ":")
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte("ideajoo123"))
+	if user != "ideajoo" || len(salt) != 32 || hash != hex.EncodeToString(mac.Sum(nil)) {
+		t.Fatalf("unexpected rpcauth line %s", rpcAuthLine)
+	}
+	fmt.Printf("\n\n== result ==\n%s\n", rpcAuthLine)
+}
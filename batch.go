@@ -0,0 +1,138 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// BatchCall is one queued call of a Batch. After Batch.Send, Err holds the
+// per-call error (an *RPCError when bitcoind rejected only this call).
+type BatchCall struct {
+	Method string
+	Params []interface{}
+	Err    error
+
+	id     string
+	result interface{}
+}
+
+// Batch sends many JSON-RPC calls to bitcoind in a single HTTP POST.
+type Batch struct {
+	bitcoinRpc BitcoinRpc
+	calls      []*BatchCall
+}
+
+func (bitcoinRpc BitcoinRpc) NewBatch() (batch *Batch) {
+	batch = &Batch{bitcoinRpc: bitcoinRpc, calls: make([]*BatchCall, 0)}
+	return
+}
+
+// Queue adds a call to the batch. On Send the call's "result" is unmarshalled
+// into result, which should be a pointer (or nil to discard the result).
+func (batch *Batch) Queue(method string, params []interface{}, result interface{}) (call *BatchCall) {
+
+	if params == nil {
+		params = []interface{}{}
+	}
+	call = &BatchCall{Method: method, Params: params, result: result}
+	batch.calls = append(batch.calls, call)
+	return
+}
+
+func (batch *Batch) GetBlockHash(blockNumber int64, blockHash *string) (call *BatchCall) {
+	return batch.Queue("getblockhash", []interface{}{blockNumber}, blockHash)
+}
+
+func (batch *Batch) GetBlock(blockHash string, block *Block) (call *BatchCall) {
+	return batch.Queue("getblock", []interface{}{blockHash}, block)
+}
+
+func (batch *Batch) GetRawTransaction(txID string, rawTx *RawTransaction) (call *BatchCall) {
+	return batch.Queue("getrawtransaction", []interface{}{txID, true}, rawTx)
+}
+
+func (batch *Batch) Len() int {
+	return len(batch.calls)
+}
+
+// Send posts every queued call at once and matches the responses by id.
+// The returned error is only set when the whole batch failed (transport,
+// authentication, malformed response); it is then also copied to every call.
+func (batch *Batch) Send(ctx context.Context) (err error) {
+
+	if len(batch.calls) == 0 {
+		return
+	}
+
+	jsonRpcInfos := make([]map[string]interface{}, 0, len(batch.calls))
+	callsByID := make(map[string]*BatchCall)
+	for _, call := range batch.calls {
+		jsonRpcInfo := defaultJsonRpcInfo()
+		jsonRpcInfo["method"] = call.Method
+		jsonRpcInfo["params"] = call.Params
+		call.id = jsonRpcInfo["id"].(string)
+		call.Err = nil
+		callsByID[call.id] = call
+		jsonRpcInfos = append(jsonRpcInfos, jsonRpcInfo)
+	}
+
+	defer func() {
+		if err != nil {
+			for _, call := range batch.calls {
+				call.Err = err
+			}
+		}
+	}()
+
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfos)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfos): %v", err)
+		return
+	}
+
+	body, err := batch.bitcoinRpc.request(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@batch.bitcoinRpc.request(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type batchResponse struct {
+		ID     string          `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	responses := make([]batchResponse, 0)
+	err = json.Unmarshal(body, &responses)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &responses): %v", err)
+		return
+	}
+
+	answered := make(map[string]bool)
+	for _, response := range responses {
+		call, ok := callsByID[response.ID]
+		if !ok {
+			continue
+		}
+		answered[response.ID] = true
+		if response.Error != nil {
+			call.Err = response.Error
+			continue
+		}
+		if call.result != nil && len(response.Result) > 0 {
+			errUnmarshal := json.Unmarshal(response.Result, call.result)
+			if errUnmarshal != nil {
+				call.Err = fmt.Errorf("@json.Unmarshal(response.Result, call.result): %v", errUnmarshal)
+			}
+		}
+	}
+
+	for _, call := range batch.calls {
+		if !answered[call.id] {
+			call.Err = fmt.Errorf("no response for %s (id %s)", call.Method, call.id)
+		}
+	}
+
+	return
+}
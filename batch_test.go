@@ -0,0 +1,64 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests := make([]map[string]interface{}, 0)
+		if err := json.Unmarshal(body, &requests); err != nil {
+			t.Errorf("batch must be a json array: %v", err)
+			return
+		}
+		// answer in reverse order to check matching by id
+		responses := make([]map[string]interface{}, 0)
+		for i := len(requests) - 1; i >= 0; i-- {
+			params := requests[i]["params"].([]interface{})
+			response := map[string]interface{}{"id": requests[i]["id"], "result": nil, "error": nil}
+			switch params[0].(float64) {
+			case 2344981:
+				response["result"] = "000000000000e7f3e8f60f9431725df65cdeb5c13386f03edaba73269e2d313d"
+			default:
+				response["error"] = map[string]interface{}{"code": -8, "message": "Block height out of range"}
+			}
+			responses = append(responses, response)
+		}
+		json.NewEncoder(w).Encode(responses)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	bitcoinRpc := BitcoinRpc{
+		RpcUser:    "ideajoo",
+		RpcPW:      "ideajoo123",
+		RpcConnect: serverURL.Hostname(),
+		RpcPort:    serverURL.Port(),
+	}
+
+	batch := bitcoinRpc.NewBatch()
+	var blockHash, missingBlockHash string
+	callOk := batch.GetBlockHash(2344981, &blockHash)
+	callMissing := batch.GetBlockHash(99999999, &missingBlockHash)
+	err := batch.Send(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if callOk.Err != nil || blockHash != "000000000000e7f3e8f60f9431725df65cdeb5c13386f03edaba73269e2d313d" {
+		t.Fatalf("unexpected result %q, %v", blockHash, callOk.Err)
+	}
+	if !errors.Is(callMissing.Err, ErrInvalidParameter) {
+		t.Fatalf("expected ErrInvalidParameter, got %v", callMissing.Err)
+	}
+	fmt.Printf("\n\n== result ==\n%s\n%v\n", blockHash, callMissing.Err)
+}
@@ -0,0 +1,261 @@
+package gobitcoinclilight
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// BitcoinConf holds the settings of a bitcoin.conf, resolved for its network.
+type BitcoinConf struct {
+	Network string // "main", "test", "testnet4", "signet" or "regtest"
+	DataDir string // base datadir, without the network subdirectory
+
+	values map[string]map[string][]string // section ("" for top level) -> key -> values
+}
+
+var defaultRpcPorts = map[string]string{
+	"main":     "8332",
+	"test":     "18332",
+	"testnet4": "48332",
+	"signet":   "38332",
+	"regtest":  "18443",
+}
+
+var networkDataDirs = map[string]string{
+	"main":     "",
+	"test":     "testnet3",
+	"testnet4": "testnet4",
+	"signet":   "signet",
+	"regtest":  "regtest",
+}
+
+// options which bitcoind only takes from the top level on mainnet
+var networkOnlyOptions = map[string]bool{
+	"addnode": true,
+	"connect": true,
+	"port":    true,
+	"bind":    true,
+	"rpcport": true,
+	"rpcbind": true,
+	"wallet":  true,
+}
+
+func DefaultDataDir() (dataDir string) {
+
+	homeDir, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		dataDir = filepath.Join(os.Getenv("APPDATA"), "Bitcoin")
+	case "darwin":
+		dataDir = filepath.Join(homeDir, "Library", "Application Support", "Bitcoin")
+	default:
+		dataDir = filepath.Join(homeDir, ".bitcoin")
+	}
+	return
+}
+
+// ParseBitcoinConf reads "key=value" lines, "[section]" headers and "section.key=value"
+// prefixed options. includeconf is not followed.
+func ParseBitcoinConf(reader io.Reader) (conf BitcoinConf, err error) {
+
+	conf.values = map[string]map[string][]string{"": {}}
+	section := ""
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if commentIndex := strings.Index(line, "#"); commentIndex >= 0 {
+			line = line[:commentIndex]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := networkDataDirs[section]; !ok {
+				err = fmt.Errorf("line %d: incorrect section[%s]", lineNumber, section)
+				return
+			}
+			if conf.values[section] == nil {
+				conf.values[section] = make(map[string][]string)
+			}
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			err = fmt.Errorf("line %d: missing '=' in [%s]", lineNumber, line)
+			return
+		}
+		key = strings.TrimPrefix(strings.TrimSpace(key), "-")
+		value = strings.TrimSpace(value)
+
+		keySection := section
+		if prefix, prefixedKey, ok := strings.Cut(key, "."); ok {
+			keySection, key = prefix, prefixedKey
+			if _, ok := networkDataDirs[keySection]; !ok {
+				err = fmt.Errorf("line %d: incorrect section[%s]", lineNumber, keySection)
+				return
+			}
+		}
+		if conf.values[keySection] == nil {
+			conf.values[keySection] = make(map[string][]string)
+		}
+		conf.values[keySection][key] = append(conf.values[keySection][key], value)
+	}
+	err = scanner.Err()
+	if err != nil {
+		err = fmt.Errorf("@scanner.Err(): %w", err)
+		return
+	}
+
+	conf.Network = "main"
+	if chain, ok := conf.values[""]["chain"]; ok {
+		conf.Network = chain[0]
+	}
+	networkFlags := 0
+	for key, network := range map[string]string{"testnet": "test", "testnet4": "testnet4", "signet": "signet", "regtest": "regtest"} {
+		if values, ok := conf.values[""][key]; ok && values[0] == "1" {
+			conf.Network = network
+			networkFlags++
+		}
+	}
+	if networkFlags > 1 {
+		err = fmt.Errorf("more than one of testnet, testnet4, signet and regtest is set")
+		return
+	}
+	if _, ok := networkDataDirs[conf.Network]; !ok {
+		err = fmt.Errorf("incorrect chain[%s]", conf.Network)
+		return
+	}
+
+	if dataDirs, ok := conf.values[""]["datadir"]; ok {
+		conf.DataDir = dataDirs[0]
+	}
+
+	return
+}
+
+// LoadBitcoinConf parses confPath, or <dataDir>/bitcoin.conf when confPath is empty.
+// An empty dataDir falls back to the datadir= option, then to the OS default.
+func LoadBitcoinConf(confPath string, dataDir string) (conf BitcoinConf, err error) {
+
+	if confPath == "" {
+		if dataDir == "" {
+			dataDir = DefaultDataDir()
+		}
+		confPath = filepath.Join(dataDir, "bitcoin.conf")
+	}
+
+	confFile, err := os.Open(confPath)
+	if err != nil {
+		err = fmt.Errorf("@os.Open(confPath): %w", err)
+		return
+	}
+	defer confFile.Close()
+
+	conf, err = ParseBitcoinConf(confFile)
+	if err != nil {
+		err = fmt.Errorf("@ParseBitcoinConf(confFile): %w", err)
+		return
+	}
+
+	if dataDir != "" {
+		conf.DataDir = dataDir
+	}
+	if conf.DataDir == "" {
+		conf.DataDir = DefaultDataDir()
+	}
+
+	return
+}
+
+// Get returns the first value of key for conf.Network: the [network] section
+// (or network.key) wins over the top level, which mainnet-only options ignore off mainnet.
+func (conf BitcoinConf) Get(key string) (value string, ok bool) {
+
+	if values, found := conf.values[conf.Network][key]; found {
+		return values[0], true
+	}
+	if conf.Network != "main" && networkOnlyOptions[key] {
+		return
+	}
+	if values, found := conf.values[""][key]; found {
+		return values[0], true
+	}
+	return
+}
+
+// NetworkDataDir is the datadir of conf.Network, e.g. ~/.bitcoin/testnet3.
+func (conf BitcoinConf) NetworkDataDir() string {
+	return filepath.Join(conf.DataDir, networkDataDirs[conf.Network])
+}
+
+// ClientOptions translates the rpc settings: rpcconnect (default 127.0.0.1), rpcport
+// (default port of the network), rpcuser/rpcpassword, or else the cookie file.
+func (conf BitcoinConf) ClientOptions() (opts []ClientOption) {
+
+	rpcConnect, ok := conf.Get("rpcconnect")
+	if !ok || rpcConnect == "" {
+		rpcConnect = "127.0.0.1"
+	}
+	rpcPort, ok := conf.Get("rpcport")
+	if !ok || rpcPort == "" {
+		rpcPort = defaultRpcPorts[conf.Network]
+	}
+	if host, port, errSplit := net.SplitHostPort(rpcConnect); errSplit == nil {
+		rpcConnect = host
+		if _, ok := conf.Get("rpcport"); !ok {
+			rpcPort = port
+		}
+	}
+	// e.g. rpcconnect=[::1] without a port, the brackets being added back by the URL
+	rpcConnect = strings.TrimSuffix(strings.TrimPrefix(rpcConnect, "["), "]")
+	opts = append(opts, WithHost(rpcConnect, rpcPort))
+
+	rpcUser, _ := conf.Get("rpcuser")
+	rpcPW, _ := conf.Get("rpcpassword")
+	if rpcPW != "" {
+		opts = append(opts, WithCredentials(rpcUser, rpcPW))
+		return
+	}
+
+	cookiePath, ok := conf.Get("rpccookiefile")
+	if !ok || cookiePath == "" {
+		cookiePath = ".cookie"
+	}
+	if !filepath.IsAbs(cookiePath) {
+		cookiePath = filepath.Join(conf.NetworkDataDir(), cookiePath)
+	}
+	opts = append(opts, WithCookieFile(cookiePath))
+
+	return
+}
+
+// NewClientFromConf loads bitcoin.conf (see LoadBitcoinConf) and builds a client
+// from it; opts are applied after the settings of the file.
+func NewClientFromConf(confPath string, dataDir string, opts ...ClientOption) (bitcoinRpc BitcoinRpc, err error) {
+
+	conf, err := LoadBitcoinConf(confPath, dataDir)
+	if err != nil {
+		err = fmt.Errorf("@LoadBitcoinConf(confPath, dataDir): %w", err)
+		return
+	}
+
+	bitcoinRpc, err = NewClient(append(conf.ClientOptions(), opts...)...)
+	if err != nil {
+		err = fmt.Errorf("@NewClient(...): %w", err)
+		return
+	}
+	return
+}
@@ -0,0 +1,89 @@
+package gobitcoinclilight
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseBitcoinConf(t *testing.T) {
+
+	conf, err := ParseBitcoinConf(strings.NewReader(`
+# ops managed
+testnet=1
+server=1
+rpcuser=ideajoo
+rpcpassword=ideajoo123 # inline comment
+rpcport=9999
+
+[main]
+rpcport=8000
+
+[test]
+rpcconnect=10.0.0.7
+regtest.rpcport=19000
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.Network != "test" {
+		t.Fatalf("unexpected network %s", conf.Network)
+	}
+	if rpcPW, _ := conf.Get("rpcpassword"); rpcPW != "ideajoo123" {
+		t.Fatalf("unexpected rpcpassword %q", rpcPW)
+	}
+	// top level rpcport only applies to mainnet
+	if _, ok := conf.Get("rpcport"); ok {
+		t.Fatalf("top level rpcport must be ignored on testnet")
+	}
+
+	bitcoinRpc, err := NewClient(conf.ClientOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bitcoinRpc.RpcConnect != "10.0.0.7" || bitcoinRpc.RpcPort != "18332" || bitcoinRpc.RpcUser != "ideajoo" {
+		t.Fatalf("unexpected bitcoinRpc %+v", bitcoinRpc)
+	}
+
+	for rpcConnect, expected := range map[string][2]string{"[::1]": {"::1", "18332"}, "::1": {"::1", "18332"}, "[::1]:18000": {"::1", "18000"}} {
+		conf.values["test"]["rpcconnect"] = []string{rpcConnect}
+		bitcoinRpc, err = NewClient(conf.ClientOptions()...)
+		if err != nil || bitcoinRpc.RpcConnect != expected[0] || bitcoinRpc.RpcPort != expected[1] {
+			t.Fatalf("%s: unexpected bitcoinRpc %+v: %v", rpcConnect, bitcoinRpc, err)
+		}
+	}
+
+	conf.Network = "main"
+	if rpcPort, _ := conf.Get("rpcport"); rpcPort != "8000" {
+		t.Fatalf("[main] rpcport must win, got %s", rpcPort)
+	}
+	conf.Network = "regtest"
+	if rpcPort, _ := conf.Get("rpcport"); rpcPort != "19000" {
+		t.Fatalf("regtest.rpcport must apply, got %s", rpcPort)
+	}
+
+	if _, err = ParseBitcoinConf(strings.NewReader("[mainnet]\n")); err == nil {
+		t.Fatalf("expected an error for an unknown section")
+	}
+}
+
+func TestNewClientFromConf(t *testing.T) {
+
+	dataDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dataDir, "bitcoin.conf"), []byte("regtest=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	bitcoinRpc, err := NewClientFromConf("", dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bitcoinRpc.RpcPort != "18443" {
+		t.Fatalf("unexpected regtest port %s", bitcoinRpc.RpcPort)
+	}
+	cookieAuth, ok := bitcoinRpc.RpcAuth.(*CookieAuth)
+	if !ok || cookieAuth.CookiePath != filepath.Join(dataDir, "regtest", ".cookie") {
+		t.Fatalf("expected cookie auth in the regtest datadir, got %+v", bitcoinRpc.RpcAuth)
+	}
+}
@@ -0,0 +1,540 @@
+package bitcoindtest
+
+import "encoding/json"
+
+// Canned testnet results, consistent with each other: the signed transaction
+// of SignRawTransactionWithKeyFixture is the one of GetRawTransactionFixture.
+const (
+	FixtureAddress     = "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh"
+	FixturePrivKey     = "cQLN8Z38G7MJk82JMFbuQcXSfQGHeZKshWJ4haSmnb9AxX9Et4Vy"
+	FixtureBlockCount  = 2344981
+	FixtureBlockHash   = "000000000000e7f3e8f60f9431725df65cdeb5c13386f03edaba73269e2d313d"
+	FixtureTxID        = "fb92e4a2aab9e55f11dfe3bf047a8d37fde0b274e99cee08db943f12f6975788"
+	FixtureRawTx       = "020000000244199d95b6dc4eb1d6b7dc9dddf9f092751fa41ea739d3c46b32b69b9f0beab00100000000fdffffff55a4a5010bca54b6fdd507cf9850c95142a2fab14db7ec7530b2bba76f6579980100000000fdffffff020000000000000000246a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874c05d0000000000001600143938a2e285bff79dc6f96a8e9a96d54c6ce7586c00000000"
+	FixtureChildTxID   = "3c8a5f3e2b1d0c9f8e7d6c5b4a39281706f5e4d3c2b1a09f8e7d6c5b4a392817"
+	FixtureSignedRawTx = "0200000000010244199d95b6dc4eb1d6b7dc9dddf9f092751fa41ea739d3c46b32b69b9f0beab00100000000fdffffff55a4a5010bca54b6fdd507cf9850c95142a2fab14db7ec7530b2bba76f6579980100000000fdffffff020000000000000000246a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874c05d0000000000001600143938a2e285bff79dc6f96a8e9a96d54c6ce7586c02473044022032b8e51b0e6be0846f2bd458919e3dad85d3923afce20ff6c3494a63eb88014002204c136999d2a60f23e12bbaa5f5a1e9e0704c00441defd77bb7c55ce86a538f4c01210307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b520247304402203800d79251b9eaf995549ee9c64c43a46fa33071a43dcac76f6d9328e67e2177022008ec36403a89ec8bbbea163932bd4048c93431336a6c0f94db6c749b631304ee01210307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b5200000000"
+)
+
+var ListUnspentFixture = json.RawMessage(`[
+  {
+    "txid": "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944",
+    "vout": 1,
+    "address": "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh",
+    "label": "",
+    "scriptPubKey": "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+    "amount": 0.00015000,
+    "confirmations": 120,
+    "spendable": true,
+    "solvable": true,
+    "desc": "wpkh([3938a2e2/0h/0h/1h]0307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52)#0hf2ja3f",
+    "parent_descs": [],
+    "safe": true
+  },
+  {
+    "txid": "9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455",
+    "vout": 1,
+    "address": "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh",
+    "label": "",
+    "scriptPubKey": "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+    "amount": 0.00010000,
+    "confirmations": 87,
+    "spendable": true,
+    "solvable": true,
+    "desc": "wpkh([3938a2e2/0h/0h/1h]0307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52)#0hf2ja3f",
+    "parent_descs": [],
+    "safe": true
+  }
+]`)
+
+var CreateRawTransactionFixture = json.RawMessage(`"` + FixtureRawTx + `"`)
+
+var SignRawTransactionWithKeyFixture = json.RawMessage(`{
+  "hex": "` + FixtureSignedRawTx + `",
+  "complete": true
+}`)
+
+var GetBlockFixture = json.RawMessage(`{
+  "hash": "000000000000e7f3e8f60f9431725df65cdeb5c13386f03edaba73269e2d313d",
+  "confirmations": 1,
+  "height": 2344981,
+  "version": 536870912,
+  "versionHex": "20000000",
+  "merkleroot": "4f3d3c2b1a0918f7e6d5c4b3a291807f6e5d4c3b2a19087f6e5d4c3b2a190817",
+  "time": 1665900013,
+  "mediantime": 1665896214,
+  "nonce": 3436563209,
+  "bits": "1a01a3c9",
+  "difficulty": 10241079.01843548,
+  "chainwork": "00000000000000000000000000000000000000000000078e30d1ae2ef9bb4b6f",
+  "nTx": 2,
+  "previousblockhash": "0000000000000118aebf3eb6d2c9ab1b5e9f5cd1fe10cb6f1a1d1c3ba40d2bd1",
+  "strippedsize": 411,
+  "size": 663,
+  "weight": 1896,
+  "tx": [
+    "0d4dfb4a3e2d0b21a9b37ab3b3a9c0b9e0bbd7bdb08d37b5cbb2a16bd8c3bb4e",
+    "fb92e4a2aab9e55f11dfe3bf047a8d37fde0b274e99cee08db943f12f6975788"
+  ]
+}`)
+
+// GetBlockVerbosity2Fixture is GetBlockFixture with verbosity 2: the decoded
+// coinbase and the transaction of GetRawTransactionFixture.
+var GetBlockVerbosity2Fixture = json.RawMessage(`{
+  "hash": "000000000000e7f3e8f60f9431725df65cdeb5c13386f03edaba73269e2d313d",
+  "confirmations": 1,
+  "height": 2344981,
+  "version": 536870912,
+  "versionHex": "20000000",
+  "merkleroot": "4f3d3c2b1a0918f7e6d5c4b3a291807f6e5d4c3b2a19087f6e5d4c3b2a190817",
+  "time": 1665900013,
+  "mediantime": 1665896214,
+  "nonce": 3436563209,
+  "bits": "1a01a3c9",
+  "difficulty": 10241079.01843548,
+  "chainwork": "00000000000000000000000000000000000000000000078e30d1ae2ef9bb4b6f",
+  "nTx": 2,
+  "previousblockhash": "0000000000000118aebf3eb6d2c9ab1b5e9f5cd1fe10cb6f1a1d1c3ba40d2bd1",
+  "strippedsize": 411,
+  "size": 663,
+  "weight": 1896,
+  "tx": [
+    {
+      "txid": "0d4dfb4a3e2d0b21a9b37ab3b3a9c0b9e0bbd7bdb08d37b5cbb2a16bd8c3bb4e",
+      "hash": "0d4dfb4a3e2d0b21a9b37ab3b3a9c0b9e0bbd7bdb08d37b5cbb2a16bd8c3bb4e",
+      "version": 2,
+      "size": 97,
+      "vsize": 97,
+      "weight": 388,
+      "locktime": 0,
+      "vin": [
+        {
+          "coinbase": "03157c2300",
+          "sequence": 4294967295
+        }
+      ],
+      "vout": [
+        {
+          "value": 0.04883789,
+          "n": 0,
+          "scriptPubKey": {
+            "asm": "0 3938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+            "desc": "addr(tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh)#s7xplvqm",
+            "hex": "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+            "address": "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh",
+            "type": "witness_v0_keyhash"
+          }
+        }
+      ]
+    },
+    {
+      "txid": "fb92e4a2aab9e55f11dfe3bf047a8d37fde0b274e99cee08db943f12f6975788",
+      "hash": "7001cbcdf666a53dda45eff4540b53b17617644d127b0f023c901e20e8db71a5",
+      "version": 2,
+      "size": 384,
+      "vsize": 222,
+      "weight": 888,
+      "locktime": 0,
+      "vin": [
+        {
+          "txid": "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944",
+          "vout": 1,
+          "scriptSig": {"asm": "", "hex": ""},
+          "txinwitness": [
+            "3044022032b8e51b0e6be0846f2bd458919e3dad85d3923afce20ff6c3494a63eb88014002204c136999d2a60f23e12bbaa5f5a1e9e0704c00441defd77bb7c55ce86a538f4c01",
+            "0307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52"
+          ],
+          "sequence": 4294967293
+        },
+        {
+          "txid": "9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455",
+          "vout": 1,
+          "scriptSig": {"asm": "", "hex": ""},
+          "txinwitness": [
+            "304402203800d79251b9eaf995549ee9c64c43a46fa33071a43dcac76f6d9328e67e2177022008ec36403a89ec8bbbea163932bd4048c93431336a6c0f94db6c749b631304ee01",
+            "0307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52"
+          ],
+          "sequence": 4294967293
+        }
+      ],
+      "vout": [
+        {
+          "value": 0.00000000,
+          "n": 0,
+          "scriptPubKey": {
+            "asm": "OP_RETURN 48454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874",
+            "desc": "raw(6a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874)#2kfkzzqe",
+            "hex": "6a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874",
+            "type": "nulldata"
+          }
+        },
+        {
+          "value": 0.00024000,
+          "n": 1,
+          "scriptPubKey": {
+            "asm": "0 3938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+            "desc": "addr(tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh)#s7xplvqm",
+            "hex": "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+            "address": "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh",
+            "type": "witness_v0_keyhash"
+          }
+        }
+      ],
+      "fee": 0.00001000,
+      "hex": "` + FixtureSignedRawTx + `"
+    }
+  ]
+}`)
+
+var GetBlockHeaderFixture = json.RawMessage(`{
+  "hash": "000000000000e7f3e8f60f9431725df65cdeb5c13386f03edaba73269e2d313d",
+  "confirmations": 1,
+  "height": 2344981,
+  "version": 536870912,
+  "versionHex": "20000000",
+  "merkleroot": "4f3d3c2b1a0918f7e6d5c4b3a291807f6e5d4c3b2a19087f6e5d4c3b2a190817",
+  "time": 1665900013,
+  "mediantime": 1665896214,
+  "nonce": 3436563209,
+  "bits": "1a01a3c9",
+  "difficulty": 10241079.01843548,
+  "chainwork": "00000000000000000000000000000000000000000000078e30d1ae2ef9bb4b6f",
+  "nTx": 2,
+  "previousblockhash": "0000000000000118aebf3eb6d2c9ab1b5e9f5cd1fe10cb6f1a1d1c3ba40d2bd1"
+}`)
+
+var GetBlockchainInfoFixture = json.RawMessage(`{
+  "chain": "test",
+  "blocks": 2344981,
+  "headers": 2344981,
+  "bestblockhash": "000000000000e7f3e8f60f9431725df65cdeb5c13386f03edaba73269e2d313d",
+  "difficulty": 10241079.01843548,
+  "time": 1665900013,
+  "mediantime": 1665896214,
+  "verificationprogress": 0.9999986,
+  "initialblockdownload": false,
+  "chainwork": "00000000000000000000000000000000000000000000078e30d1ae2ef9bb4b6f",
+  "size_on_disk": 1842136591,
+  "pruned": true,
+  "pruneheight": 2319810,
+  "automatic_pruning": true,
+  "prune_target_size": 2147483648,
+  "warnings": ""
+}`)
+
+var GetChainTipsFixture = json.RawMessage(`[
+  {
+    "height": 2344981,
+    "hash": "000000000000e7f3e8f60f9431725df65cdeb5c13386f03edaba73269e2d313d",
+    "branchlen": 0,
+    "status": "active"
+  },
+  {
+    "height": 2344977,
+    "hash": "00000000000000a4a1a7c3b34e1b8c3ce3d68e1b0d6c17c1e5b1a4d0f7c9b2e1",
+    "branchlen": 1,
+    "status": "valid-fork"
+  }
+]`)
+
+var GetMempoolInfoFixture = json.RawMessage(`{
+  "loaded": true,
+  "size": 2,
+  "bytes": 363,
+  "usage": 2784,
+  "total_fee": 0.00002410,
+  "maxmempool": 300000000,
+  "mempoolminfee": 0.00001000,
+  "minrelaytxfee": 0.00001000,
+  "incrementalrelayfee": 0.00001000,
+  "unbroadcastcount": 0,
+  "fullrbf": true
+}`)
+
+// GetMempoolEntryFixture is the transaction of GetRawTransactionFixture before
+// it confirmed, with a descendant spending its change.
+var GetMempoolEntryFixture = json.RawMessage(`{
+  "vsize": 222,
+  "weight": 888,
+  "time": 1665899412,
+  "height": 2344980,
+  "descendantcount": 2,
+  "descendantsize": 363,
+  "ancestorcount": 1,
+  "ancestorsize": 222,
+  "wtxid": "7001cbcdf666a53dda45eff4540b53b17617644d127b0f023c901e20e8db71a5",
+  "fees": {
+    "base": 0.00001000,
+    "modified": 0.00001000,
+    "ancestor": 0.00001000,
+    "descendant": 0.00002410
+  },
+  "depends": [],
+  "spentby": ["` + FixtureChildTxID + `"],
+  "bip125-replaceable": true,
+  "unbroadcast": false
+}`)
+
+var TestMempoolAcceptFixture = json.RawMessage(`[
+  {
+    "txid": "fb92e4a2aab9e55f11dfe3bf047a8d37fde0b274e99cee08db943f12f6975788",
+    "wtxid": "7001cbcdf666a53dda45eff4540b53b17617644d127b0f023c901e20e8db71a5",
+    "allowed": true,
+    "vsize": 222,
+    "fees": {
+      "base": 0.00001000,
+      "effective-feerate": 0.00004504,
+      "effective-includes": ["7001cbcdf666a53dda45eff4540b53b17617644d127b0f023c901e20e8db71a5"]
+    }
+  }
+]`)
+
+var GetRawTransactionFixture = json.RawMessage(`{
+  "txid": "fb92e4a2aab9e55f11dfe3bf047a8d37fde0b274e99cee08db943f12f6975788",
+  "hash": "7001cbcdf666a53dda45eff4540b53b17617644d127b0f023c901e20e8db71a5",
+  "version": 2,
+  "size": 384,
+  "vsize": 222,
+  "weight": 888,
+  "locktime": 0,
+  "vin": [
+    {
+      "txid": "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944",
+      "vout": 1,
+      "scriptSig": {"asm": "", "hex": ""},
+      "txinwitness": [
+        "3044022032b8e51b0e6be0846f2bd458919e3dad85d3923afce20ff6c3494a63eb88014002204c136999d2a60f23e12bbaa5f5a1e9e0704c00441defd77bb7c55ce86a538f4c01",
+        "0307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52"
+      ],
+      "sequence": 4294967293
+    },
+    {
+      "txid": "9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455",
+      "vout": 1,
+      "scriptSig": {"asm": "", "hex": ""},
+      "txinwitness": [
+        "304402203800d79251b9eaf995549ee9c64c43a46fa33071a43dcac76f6d9328e67e2177022008ec36403a89ec8bbbea163932bd4048c93431336a6c0f94db6c749b631304ee01",
+        "0307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52"
+      ],
+      "sequence": 4294967293
+    }
+  ],
+  "vout": [
+    {
+      "value": 0.00000000,
+      "n": 0,
+      "scriptPubKey": {
+        "asm": "OP_RETURN 48454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874",
+        "desc": "raw(6a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874)#2kfkzzqe",
+        "hex": "6a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874",
+        "type": "nulldata"
+      }
+    },
+    {
+      "value": 0.00024000,
+      "n": 1,
+      "scriptPubKey": {
+        "asm": "0 3938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+        "desc": "addr(tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh)#s7xplvqm",
+        "hex": "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+        "address": "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh",
+        "type": "witness_v0_keyhash"
+      }
+    }
+  ],
+  "hex": "` + FixtureSignedRawTx + `",
+  "blockhash": "000000000000e7f3e8f60f9431725df65cdeb5c13386f03edaba73269e2d313d",
+  "confirmations": 1,
+  "time": 1665900013,
+  "blocktime": 1665900013
+}`)
+
+// GetRawTransactionVerbosity2Fixture is GetRawTransactionFixture with verbosity 2:
+// the prevouts of the inputs and the fee.
+var GetRawTransactionVerbosity2Fixture = json.RawMessage(`{
+  "txid": "fb92e4a2aab9e55f11dfe3bf047a8d37fde0b274e99cee08db943f12f6975788",
+  "hash": "7001cbcdf666a53dda45eff4540b53b17617644d127b0f023c901e20e8db71a5",
+  "version": 2,
+  "size": 384,
+  "vsize": 222,
+  "weight": 888,
+  "locktime": 0,
+  "vin": [
+    {
+      "txid": "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944",
+      "vout": 1,
+      "scriptSig": {"asm": "", "hex": ""},
+      "txinwitness": [
+        "3044022032b8e51b0e6be0846f2bd458919e3dad85d3923afce20ff6c3494a63eb88014002204c136999d2a60f23e12bbaa5f5a1e9e0704c00441defd77bb7c55ce86a538f4c01",
+        "0307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52"
+      ],
+      "prevout": {
+        "generated": false,
+        "height": 2344862,
+        "value": 0.00015000,
+        "scriptPubKey": {
+          "asm": "0 3938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+          "desc": "addr(tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh)#s7xplvqm",
+          "hex": "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+          "address": "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh",
+          "type": "witness_v0_keyhash"
+        }
+      },
+      "sequence": 4294967293
+    },
+    {
+      "txid": "9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455",
+      "vout": 1,
+      "scriptSig": {"asm": "", "hex": ""},
+      "txinwitness": [
+        "304402203800d79251b9eaf995549ee9c64c43a46fa33071a43dcac76f6d9328e67e2177022008ec36403a89ec8bbbea163932bd4048c93431336a6c0f94db6c749b631304ee01",
+        "0307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52"
+      ],
+      "prevout": {
+        "generated": false,
+        "height": 2344895,
+        "value": 0.00010000,
+        "scriptPubKey": {
+          "asm": "0 3938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+          "desc": "addr(tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh)#s7xplvqm",
+          "hex": "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+          "address": "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh",
+          "type": "witness_v0_keyhash"
+        }
+      },
+      "sequence": 4294967293
+    }
+  ],
+  "vout": [
+    {
+      "value": 0.00000000,
+      "n": 0,
+      "scriptPubKey": {
+        "asm": "OP_RETURN 48454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874",
+        "desc": "raw(6a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874)#2kfkzzqe",
+        "hex": "6a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874",
+        "type": "nulldata"
+      }
+    },
+    {
+      "value": 0.00024000,
+      "n": 1,
+      "scriptPubKey": {
+        "asm": "0 3938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+        "desc": "addr(tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh)#s7xplvqm",
+        "hex": "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+        "address": "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh",
+        "type": "witness_v0_keyhash"
+      }
+    }
+  ],
+  "fee": 0.00001000,
+  "hex": "` + FixtureSignedRawTx + `",
+  "blockhash": "000000000000e7f3e8f60f9431725df65cdeb5c13386f03edaba73269e2d313d",
+  "confirmations": 1,
+  "time": 1665900013,
+  "blocktime": 1665900013
+}`)
+
+var DecodeRawTransactionFixture = json.RawMessage(`{
+  "txid": "fb92e4a2aab9e55f11dfe3bf047a8d37fde0b274e99cee08db943f12f6975788",
+  "hash": "7001cbcdf666a53dda45eff4540b53b17617644d127b0f023c901e20e8db71a5",
+  "version": 2,
+  "size": 384,
+  "vsize": 222,
+  "weight": 888,
+  "locktime": 0,
+  "vin": [
+    {
+      "txid": "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944",
+      "vout": 1,
+      "scriptSig": {"asm": "", "hex": ""},
+      "txinwitness": [
+        "3044022032b8e51b0e6be0846f2bd458919e3dad85d3923afce20ff6c3494a63eb88014002204c136999d2a60f23e12bbaa5f5a1e9e0704c00441defd77bb7c55ce86a538f4c01",
+        "0307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52"
+      ],
+      "sequence": 4294967293
+    },
+    {
+      "txid": "9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455",
+      "vout": 1,
+      "scriptSig": {"asm": "", "hex": ""},
+      "txinwitness": [
+        "304402203800d79251b9eaf995549ee9c64c43a46fa33071a43dcac76f6d9328e67e2177022008ec36403a89ec8bbbea163932bd4048c93431336a6c0f94db6c749b631304ee01",
+        "0307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52"
+      ],
+      "sequence": 4294967293
+    }
+  ],
+  "vout": [
+    {
+      "value": 0.00000000,
+      "n": 0,
+      "scriptPubKey": {
+        "asm": "OP_RETURN 48454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874",
+        "desc": "raw(6a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874)#2kfkzzqe",
+        "hex": "6a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874",
+        "type": "nulldata"
+      }
+    },
+    {
+      "value": 0.00024000,
+      "n": 1,
+      "scriptPubKey": {
+        "asm": "0 3938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+        "desc": "addr(tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh)#s7xplvqm",
+        "hex": "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+        "address": "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh",
+        "type": "witness_v0_keyhash"
+      }
+    }
+  ]
+}`)
+
+// DecodeScriptFixture decodes the scriptPubKey of FixtureAddress.
+var DecodeScriptFixture = json.RawMessage(`{
+  "asm": "0 3938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+  "desc": "addr(tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh)#s7xplvqm",
+  "address": "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh",
+  "type": "witness_v0_keyhash",
+  "p2sh": "2N9CPBaJE3aH3CohrLQtA2ZLwoprZFXhu36"
+}`)
+
+var ListReceivedByAddressFixture = json.RawMessage(`[
+  {
+    "address": "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh",
+    "amount": 0.00025000,
+    "confirmations": 87,
+    "label": "",
+    "txids": [
+      "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944",
+      "9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455"
+    ]
+  }
+]`)
+
+// Fixtures maps each method to its canned result, see Server.LoadFixtures.
+var Fixtures = map[string]json.RawMessage{
+	"listunspent":               ListUnspentFixture,
+	"createrawtransaction":      CreateRawTransactionFixture,
+	"signrawtransactionwithkey": SignRawTransactionWithKeyFixture,
+	"getblock":                  GetBlockFixture,
+	"getblockheader":            GetBlockHeaderFixture,
+	"getblockchaininfo":         GetBlockchainInfoFixture,
+	"getchaintips":              GetChainTipsFixture,
+	"getmempoolinfo":            GetMempoolInfoFixture,
+	"getmempoolentry":           GetMempoolEntryFixture,
+	"getrawmempool":             json.RawMessage(`["` + FixtureTxID + `", "` + FixtureChildTxID + `"]`),
+	"testmempoolaccept":         TestMempoolAcceptFixture,
+	"estimatesmartfee":          json.RawMessage(`{"feerate": 0.00012345, "blocks": 2}`),
+	"getdifficulty":             json.RawMessage(`10241079.01843548`),
+	"getrawtransaction":         GetRawTransactionFixture,
+	"decoderawtransaction":      DecodeRawTransactionFixture,
+	"decodescript":              DecodeScriptFixture,
+	"listreceivedbyaddress":     ListReceivedByAddressFixture,
+	"dumpprivkey":               json.RawMessage(`"` + FixturePrivKey + `"`),
+	"sendrawtransaction":        json.RawMessage(`"` + FixtureTxID + `"`),
+	"getblockcount":             json.RawMessage(`2344981`),
+	"getblockhash":              json.RawMessage(`"` + FixtureBlockHash + `"`),
+	"getnewaddress":             json.RawMessage(`"tb1qa6v5vvpagj7lqnummqff0jm086y3vq3jjc9r90"`),
+	"listwallets":               json.RawMessage(`["test"]`),
+}
@@ -0,0 +1,215 @@
+// Package bitcoindtest provides an in-process fake bitcoind speaking JSON-RPC
+// over HTTP, for testing code built on gobitcoinclilight without a node.
+package bitcoindtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+)
+
+const (
+	RpcUser = "bitcoindtest"
+	RpcPW   = "bitcoindtest"
+)
+
+// Request is one JSON-RPC call received by the server.
+type Request struct {
+	Path   string // e.g. "/wallet/test"
+	ID     json.RawMessage
+	Method string
+	Params []json.RawMessage
+	Batch  bool // sent inside a batch
+}
+
+// Param unmarshals the index-th positional parameter into v.
+func (request Request) Param(index int, v interface{}) (err error) {
+
+	if index >= len(request.Params) {
+		err = fmt.Errorf("%s: no param %d", request.Method, index)
+		return
+	}
+	err = json.Unmarshal(request.Params[index], v)
+	return
+}
+
+// Error is a JSON-RPC error object, as bitcoind sends it.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handler answers one call, with either a result (marshalled to JSON) or an error.
+type Handler func(request Request) (result interface{}, rpcError *Error)
+
+// Server is an httptest.Server answering JSON-RPC calls with the Handler
+// registered for their method; unknown methods get bitcoind's -32601.
+type Server struct {
+	*httptest.Server
+	RpcUser string
+	RpcPW   string
+
+	mutex    sync.Mutex
+	handlers map[string]Handler
+	requests []Request
+}
+
+// NewServer starts a server without handlers, expecting RpcUser/RpcPW as basic auth.
+func NewServer() (server *Server) {
+
+	server = &Server{
+		RpcUser:  RpcUser,
+		RpcPW:    RpcPW,
+		handlers: make(map[string]Handler),
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	return
+}
+
+// NewServerWithFixtures starts a server answering every method of Fixtures.
+func NewServerWithFixtures() (server *Server) {
+	server = NewServer()
+	server.LoadFixtures()
+	return
+}
+
+// LoadFixtures registers a static result for every method of Fixtures.
+func (server *Server) LoadFixtures() {
+	for method, result := range Fixtures {
+		server.SetResult(method, result)
+	}
+}
+
+func (server *Server) Handle(method string, handler Handler) {
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.handlers[method] = handler
+}
+
+// SetResult answers method with result; a json.RawMessage is sent as is.
+func (server *Server) SetResult(method string, result interface{}) {
+	server.Handle(method, func(request Request) (interface{}, *Error) {
+		return result, nil
+	})
+}
+
+func (server *Server) SetError(method string, code int, message string) {
+	server.Handle(method, func(request Request) (interface{}, *Error) {
+		return nil, &Error{Code: code, Message: message}
+	})
+}
+
+// Requests returns every call received so far, in order.
+func (server *Server) Requests() (requests []Request) {
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	requests = append(requests, server.requests...)
+	return
+}
+
+// RequestsFor returns the calls received so far for method.
+func (server *Server) RequestsFor(method string) (requests []Request) {
+	for _, request := range server.Requests() {
+		if request.Method == method {
+			requests = append(requests, request)
+		}
+	}
+	return
+}
+
+func (server *Server) Host() string {
+	serverURL, _ := url.Parse(server.URL)
+	return serverURL.Hostname()
+}
+
+func (server *Server) Port() string {
+	serverURL, _ := url.Parse(server.URL)
+	return serverURL.Port()
+}
+
+type jsonRpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type jsonRpcResponse struct {
+	Result interface{}     `json:"result"`
+	Error  *Error          `json:"error"`
+	ID     json.RawMessage `json:"id"`
+}
+
+func (server *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	rpcUser, rpcPW, ok := r.BasicAuth()
+	if server.RpcUser != "" && (!ok || rpcUser != server.RpcUser || rpcPW != server.RpcPW) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	batch := make([]jsonRpcRequest, 0)
+	if json.Unmarshal(body, &batch) == nil {
+		responses := make([]jsonRpcResponse, 0, len(batch))
+		for _, single := range batch {
+			responses = append(responses, server.call(r.URL.Path, single, true))
+		}
+		json.NewEncoder(w).Encode(responses)
+		return
+	}
+
+	single := jsonRpcRequest{}
+	if err = json.Unmarshal(body, &single); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(jsonRpcResponse{Error: &Error{Code: -32700, Message: "Parse error"}, ID: json.RawMessage("null")})
+		return
+	}
+	response := server.call(r.URL.Path, single, false)
+	if response.Error != nil {
+		// like bitcoind for JSON-RPC 1.0 requests
+		if response.Error.Code == -32601 {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (server *Server) call(path string, single jsonRpcRequest, batch bool) (response jsonRpcResponse) {
+
+	request := Request{Path: path, ID: single.ID, Method: single.Method, Params: single.Params, Batch: batch}
+	if request.ID == nil {
+		request.ID = json.RawMessage("null")
+	}
+
+	server.mutex.Lock()
+	server.requests = append(server.requests, request)
+	handler, ok := server.handlers[single.Method]
+	server.mutex.Unlock()
+
+	response.ID = request.ID
+	if !ok {
+		response.Error = &Error{Code: -32601, Message: "Method not found"}
+		return
+	}
+	response.Result, response.Error = handler(request)
+	if response.Error != nil {
+		response.Result = nil
+	}
+	return
+}
@@ -0,0 +1,85 @@
+package bitcoindtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func post(t *testing.T, server *Server, path string, body string) (statusCode int, response json.RawMessage) {
+
+	request, err := http.NewRequest(http.MethodPost, server.URL+path, bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.SetBasicAuth(server.RpcUser, server.RpcPW)
+	httpResponse, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer httpResponse.Body.Close()
+
+	json.NewDecoder(httpResponse.Body).Decode(&response)
+	statusCode = httpResponse.StatusCode
+	return
+}
+
+func TestServer(t *testing.T) {
+
+	server := NewServerWithFixtures()
+	defer server.Close()
+	server.SetError("getblockhash", -8, "Block height out of range")
+	server.Handle("getblockcount", func(request Request) (interface{}, *Error) {
+		return FixtureBlockCount + len(request.Params), nil
+	})
+
+	statusCode, response := post(t, server, "/", `{"id":"1","method":"getblockcount","params":[]}`)
+	if statusCode != http.StatusOK || string(response) != `{"result":2344981,"error":null,"id":"1"}` {
+		t.Fatalf("unexpected response %d %s", statusCode, response)
+	}
+
+	statusCode, response = post(t, server, "/", `{"id":"2","method":"getblockhash","params":[0]}`)
+	if statusCode != http.StatusInternalServerError || string(response) != `{"result":null,"error":{"code":-8,"message":"Block height out of range"},"id":"2"}` {
+		t.Fatalf("unexpected response %d %s", statusCode, response)
+	}
+
+	statusCode, _ = post(t, server, "/", `{"id":"3","method":"getmininginfo","params":[]}`)
+	if statusCode != http.StatusNotFound {
+		t.Fatalf("unexpected status %d for an unknown method", statusCode)
+	}
+
+	statusCode, response = post(t, server, "/wallet/test", `[{"id":4,"method":"dumpprivkey","params":["`+FixtureAddress+`"]},{"id":5,"method":"getmininginfo"}]`)
+	responses := make([]struct {
+		Result string `json:"result"`
+		Error  *Error `json:"error"`
+		ID     int    `json:"id"`
+	}, 0)
+	if err := json.Unmarshal(response, &responses); err != nil || statusCode != http.StatusOK || len(responses) != 2 {
+		t.Fatalf("unexpected batch response %d %s", statusCode, response)
+	}
+	if responses[0].ID != 4 || responses[0].Result != FixturePrivKey || responses[1].ID != 5 || responses[1].Error.Code != -32601 {
+		t.Fatalf("unexpected batch responses %+v", responses)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 5 || requests[3].Path != "/wallet/test" || !requests[3].Batch || requests[0].Batch {
+		t.Fatalf("unexpected requests %+v", requests)
+	}
+	address := ""
+	if err := server.RequestsFor("dumpprivkey")[0].Param(0, &address); err != nil || address != FixtureAddress {
+		t.Fatalf("unexpected param %q: %v", address, err)
+	}
+	if err := requests[0].Param(0, &address); err == nil {
+		t.Fatalf("expected an error for a missing param")
+	}
+
+	httpResponse, err := http.Post(server.URL, "application/json", bytes.NewBufferString(`{"id":"6","method":"getblockcount","params":[]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpResponse.Body.Close()
+	if httpResponse.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("unexpected status %d without auth", httpResponse.StatusCode)
+	}
+}
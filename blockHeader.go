@@ -0,0 +1,205 @@
+package gobitcoinclilight
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const RawBlockHeaderSize = 80
+
+var ErrProofOfWork = errors.New("block hash above the target of its bits")
+
+// RawBlockHeader is the 80-byte block header as serialized on the wire.
+type RawBlockHeader struct {
+	Version    int32
+	PrevBlock  Hash
+	MerkleRoot Hash
+	Time       uint32
+	Bits       uint32
+	Nonce      uint32
+}
+
+func DecodeRawBlockHeaderHex(headerHex string) (header RawBlockHeader, err error) {
+
+	data, err := hex.DecodeString(headerHex)
+	if err != nil {
+		err = fmt.Errorf("@hex.DecodeString(headerHex): %v", err)
+		return
+	}
+	return DecodeRawBlockHeader(data)
+}
+
+func DecodeRawBlockHeader(data []byte) (header RawBlockHeader, err error) {
+
+	if len(data) != RawBlockHeaderSize {
+		err = fmt.Errorf("incorrect header length %d", len(data))
+		return
+	}
+	header.Version = int32(binary.LittleEndian.Uint32(data[0:4]))
+	copy(header.PrevBlock[:], data[4:36])
+	copy(header.MerkleRoot[:], data[36:68])
+	header.Time = binary.LittleEndian.Uint32(data[68:72])
+	header.Bits = binary.LittleEndian.Uint32(data[72:76])
+	header.Nonce = binary.LittleEndian.Uint32(data[76:80])
+	return
+}
+
+func (header RawBlockHeader) Serialize() []byte {
+
+	buffer := bytes.NewBuffer(make([]byte, 0, RawBlockHeaderSize))
+	binary.Write(buffer, binary.LittleEndian, header.Version)
+	buffer.Write(header.PrevBlock[:])
+	buffer.Write(header.MerkleRoot[:])
+	binary.Write(buffer, binary.LittleEndian, header.Time)
+	binary.Write(buffer, binary.LittleEndian, header.Bits)
+	binary.Write(buffer, binary.LittleEndian, header.Nonce)
+	return buffer.Bytes()
+}
+
+func (header RawBlockHeader) Hex() string {
+	return hex.EncodeToString(header.Serialize())
+}
+
+func (header RawBlockHeader) BlockHash() Hash {
+	return hashOf(header.Serialize())
+}
+
+// CompactToTarget expands the "bits" of a header into its target.
+func CompactToTarget(bits uint32) (target *big.Int, err error) {
+
+	exponent := uint(bits >> 24)
+	mantissa := int64(bits & 0x007fffff)
+	if bits&0x00800000 != 0 && mantissa != 0 {
+		err = fmt.Errorf("negative target in bits %08x", bits)
+		return
+	}
+
+	target = big.NewInt(mantissa)
+	if exponent <= 3 {
+		target.Rsh(target, 8*(3-exponent))
+	} else {
+		target.Lsh(target, 8*(exponent-3))
+	}
+	if target.BitLen() > 256 {
+		err = fmt.Errorf("target of bits %08x overflows 256 bits", bits)
+		return
+	}
+	if target.Sign() == 0 {
+		err = fmt.Errorf("zero target in bits %08x", bits)
+		return
+	}
+	return
+}
+
+func hashToBig(hash Hash) *big.Int {
+	reversed := make([]byte, len(hash))
+	for i, b := range hash {
+		reversed[len(hash)-1-i] = b
+	}
+	return new(big.Int).SetBytes(reversed)
+}
+
+// CheckProofOfWork verifies that the header hash is at most the target of its
+// bits, and that this target is at most powLimit when powLimit is not nil.
+func (header RawBlockHeader) CheckProofOfWork(powLimit *big.Int) (err error) {
+
+	target, err := CompactToTarget(header.Bits)
+	if err != nil {
+		return
+	}
+	if powLimit != nil && target.Cmp(powLimit) > 0 {
+		err = fmt.Errorf("%w: target of bits %08x above the pow limit", ErrProofOfWork, header.Bits)
+		return
+	}
+	if hashToBig(header.BlockHash()).Cmp(target) > 0 {
+		err = fmt.Errorf("%w: %s, bits %08x", ErrProofOfWork, header.BlockHash(), header.Bits)
+		return
+	}
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetBlockHeader(blockHash string) (header BlockHeader, err error) {
+	return bitcoinRpc.GetBlockHeaderCtx(context.Background(), blockHash)
+}
+
+func (bitcoinRpc BitcoinRpc) GetBlockHeaderCtx(ctx context.Context, blockHash string) (header BlockHeader, err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "getblockheader"
+	jsonRpcInfo["params"] = []interface{}{blockHash, true}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultGetBlockHeader struct {
+		Header BlockHeader `json:"result"`
+	}
+	result := resultGetBlockHeader{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	header = result.Header
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetRawBlockHeader(blockHash string) (header RawBlockHeader, err error) {
+	return bitcoinRpc.GetRawBlockHeaderCtx(context.Background(), blockHash)
+}
+
+// GetRawBlockHeaderCtx is getblockheader with verbose=false, decoded. The hash
+// of the decoded header must be blockHash.
+func (bitcoinRpc BitcoinRpc) GetRawBlockHeaderCtx(ctx context.Context, blockHash string) (header RawBlockHeader, err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "getblockheader"
+	jsonRpcInfo["params"] = []interface{}{blockHash, false}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultGetRawBlockHeader struct {
+		HeaderHex string `json:"result"`
+	}
+	result := resultGetRawBlockHeader{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	header, err = DecodeRawBlockHeaderHex(result.HeaderHex)
+	if err != nil {
+		err = fmt.Errorf("@DecodeRawBlockHeaderHex(result.HeaderHex): %v", err)
+		return
+	}
+	if header.BlockHash().String() != blockHash {
+		err = fmt.Errorf("header hash %s instead of %s", header.BlockHash(), blockHash)
+		return
+	}
+	return
+}
@@ -0,0 +1,116 @@
+package gobitcoinclilight
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+// the first mainnet headers
+const (
+	genesisHeaderHex = "0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c"
+	genesisHash      = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+	header1Hex       = "010000006fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d6190000000000982051fd1e4ba744bbbe680e1fee14677ba1a3c3540bf7b1cdb606e857233e0e61bc6649ffff001d01e36299"
+	header1Hash      = "00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048"
+	header2Hex       = "010000004860eb18bf1b1620e37e9490fc8a427514416fd75159ab86688e9a8300000000d5fdcc541e25de1c7a5addedf24858b8bb665c9f36ef744ee42c316022c90f9bb0bc6649ffff001d08d2bd61"
+	header2Hash      = "000000006a625f06636b8bb6ac7b960a8d03705d1ace08b1a19da3fdcc99ddbd"
+)
+
+func TestRawBlockHeader(t *testing.T) {
+
+	genesis, err := DecodeRawBlockHeaderHex(genesisHeaderHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if genesis.BlockHash().String() != genesisHash || genesis.Hex() != genesisHeaderHex {
+		t.Fatalf("unexpected genesis %s", genesis.BlockHash())
+	}
+	if genesis.Version != 1 || genesis.Time != 1231006505 || genesis.Bits != 0x1d00ffff || genesis.Nonce != 2083236893 || genesis.PrevBlock != (Hash{}) {
+		t.Fatalf("unexpected genesis %+v", genesis)
+	}
+	if genesis.MerkleRoot.String() != "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b" {
+		t.Fatalf("unexpected merkle root %s", genesis.MerkleRoot)
+	}
+	if err = genesis.CheckProofOfWork(MainnetPowLimit); err != nil {
+		t.Fatal(err)
+	}
+
+	genesis.Nonce++
+	if err = genesis.CheckProofOfWork(MainnetPowLimit); !errors.Is(err, ErrProofOfWork) {
+		t.Fatalf("expected ErrProofOfWork, got %v", err)
+	}
+
+	target, err := CompactToTarget(0x1d00ffff)
+	if err != nil || target.Text(16) != "ffff0000000000000000000000000000000000000000000000000000" {
+		t.Fatalf("unexpected target %x: %v", target, err)
+	}
+	for _, bits := range []uint32{0x1d800001, 0x21010000, 0x01003456} {
+		if _, err = CompactToTarget(bits); err == nil {
+			t.Fatalf("expected an error for bits %08x", bits)
+		}
+	}
+
+	// the signet genesis, whose target is the signet pow limit
+	signetGenesis := genesis
+	signetGenesis.Time, signetGenesis.Bits, signetGenesis.Nonce = 1598918400, 0x1e0377ae, 52613770
+	if signetGenesis.BlockHash().String() != "00000008819873e925422c1ff0f99f7cc9bbb232af63a077a480a3633bee1ef6" {
+		t.Fatalf("unexpected signet genesis %s", signetGenesis.BlockHash())
+	}
+	if err = signetGenesis.CheckProofOfWork(SignetPowLimit); err != nil {
+		t.Fatal(err)
+	}
+	if err = signetGenesis.CheckProofOfWork(MainnetPowLimit); !errors.Is(err, ErrProofOfWork) {
+		t.Fatalf("expected the pow limit to be enforced, got %v", err)
+	}
+
+	regtestHeader := RawBlockHeader{Version: 4, Bits: 0x207fffff}
+	if err = regtestHeader.CheckProofOfWork(MainnetPowLimit); !errors.Is(err, ErrProofOfWork) {
+		t.Fatalf("expected the pow limit to be enforced, got %v", err)
+	}
+
+	if _, err = DecodeRawBlockHeaderHex(genesisHeaderHex[:158]); err == nil {
+		t.Fatalf("expected an error for a short header")
+	}
+}
+
+func TestGetBlockHeader(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	server.Handle("getblockheader", func(request bitcoindtest.Request) (interface{}, *bitcoindtest.Error) {
+		blockHash, verbose := "", true
+		request.Param(0, &blockHash)
+		request.Param(1, &verbose)
+		if verbose {
+			return bitcoindtest.GetBlockHeaderFixture, nil
+		}
+		switch blockHash {
+		case header1Hash:
+			return header1Hex, nil
+		case header2Hash:
+			return header1Hex, nil // a lying node
+		}
+		return nil, &bitcoindtest.Error{Code: -5, Message: "Block not found"}
+	})
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	header, err := bitcoinRpc.GetBlockHeader(bitcoindtest.FixtureBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Hash != bitcoindtest.FixtureBlockHash || header.Height != bitcoindtest.FixtureBlockCount || header.Bits != "1a01a3c9" || header.NTx != 2 || header.PreviousBlockHash == "" {
+		t.Fatalf("unexpected header %+v", header)
+	}
+
+	rawHeader, err := bitcoinRpc.GetRawBlockHeader(header1Hash)
+	if err != nil || rawHeader.PrevBlock.String() != genesisHash {
+		t.Fatalf("unexpected rawHeader %+v: %v", rawHeader, err)
+	}
+	if _, err = bitcoinRpc.GetRawBlockHeader(header2Hash); err == nil {
+		t.Fatalf("expected an error for a header of another hash")
+	}
+	if _, err = bitcoinRpc.GetRawBlockHeader(genesisHash); !errors.Is(err, ErrInvalidAddressOrKey) {
+		t.Fatalf("expected ErrInvalidAddressOrKey, got %v", err)
+	}
+}
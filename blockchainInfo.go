@@ -0,0 +1,214 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type BlockchainInfo struct {
+	Chain                string   `json:"chain"`                // (string) current network name (main, test, testnet4, signet, regtest)
+	Blocks               int64    `json:"blocks"`               // (numeric) the height of the most-work fully-validated chain
+	Headers              int64    `json:"headers"`              // (numeric) the current number of headers we have validated
+	BestBlockHash        string   `json:"bestblockhash"`        // (string) the hash of the currently best block
+	Bits                 string   `json:"bits"`                 // (string) nBits: compact representation of the block difficulty target
+	Target               string   `json:"target"`               // (string) The difficulty target
+	Difficulty           float64  `json:"difficulty"`           // (numeric) the current difficulty
+	Time                 int64    `json:"time"`                 // (numeric) The block time expressed in UNIX epoch time
+	MedianTime           int64    `json:"mediantime"`           // (numeric) The median block time expressed in UNIX epoch time
+	VerificationProgress float64  `json:"verificationprogress"` // (numeric) estimate of verification progress [0..1]
+	InitialBlockDownload bool     `json:"initialblockdownload"` // (boolean) (debug information) estimate of whether this node is in Initial Block Download mode
+	ChainWork            string   `json:"chainwork"`            // (string) total amount of work in active chain, in hexadecimal
+	SizeOnDisk           int64    `json:"size_on_disk"`         // (numeric) the estimated size of the block and undo files on disk
+	Pruned               bool     `json:"pruned"`               // (boolean) if the blocks are subject to pruning
+	PruneHeight          int64    `json:"pruneheight"`          // (numeric, optional) the first block unpruned, all previous blocks were pruned (only present if pruning is enabled)
+	AutomaticPruning     bool     `json:"automatic_pruning"`    // (boolean, optional) whether automatic pruning is enabled (only present if pruning is enabled)
+	PruneTargetSize      int64    `json:"prune_target_size"`    // (numeric, optional) the target size used by pruning (only present if automatic pruning is enabled)
+	Warnings             Warnings `json:"warnings"`             // (json array of strings, or string before v28) any network and blockchain warnings
+}
+
+// Warnings is a list of warnings; bitcoind before v28 sends a single string,
+// empty without warnings.
+type Warnings []string
+
+func (warnings *Warnings) UnmarshalJSON(data []byte) (err error) {
+
+	single := ""
+	if json.Unmarshal(data, &single) == nil {
+		*warnings = nil
+		if single != "" {
+			*warnings = Warnings{single}
+		}
+		return
+	}
+	list := []string{}
+	err = json.Unmarshal(data, &list)
+	*warnings = list
+	return
+}
+
+// IsSynced reports whether the node is out of IBD and has validated the
+// blocks of all the headers it knows.
+func (info BlockchainInfo) IsSynced() bool {
+	return !info.InitialBlockDownload && info.Headers == info.Blocks
+}
+
+func (bitcoinRpc BitcoinRpc) GetBlockchainInfo() (info BlockchainInfo, err error) {
+	return bitcoinRpc.GetBlockchainInfoCtx(context.Background())
+}
+
+func (bitcoinRpc BitcoinRpc) GetBlockchainInfoCtx(ctx context.Context) (info BlockchainInfo, err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "getblockchaininfo"
+	jsonRpcInfo["params"] = []interface{}{}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultGetBlockchainInfo struct {
+		Info BlockchainInfo `json:"result"`
+	}
+	result := resultGetBlockchainInfo{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	info = result.Info
+	return
+}
+
+// status of a chain tip in getchaintips
+const (
+	ChainTipActive       = "active"        // the tip of the active main chain
+	ChainTipValidFork    = "valid-fork"    // fully validated but not part of the active chain
+	ChainTipValidHeaders = "valid-headers" // all blocks available, but never fully validated
+	ChainTipHeadersOnly  = "headers-only"  // not all blocks for this branch are available
+	ChainTipInvalid      = "invalid"       // contains at least one invalid block
+)
+
+type ChainTip struct {
+	Height    int64  `json:"height"`    // (numeric) height of the chain tip
+	Hash      string `json:"hash"`      // (string) block hash of the tip
+	BranchLen int64  `json:"branchlen"` // (numeric) zero for main chain, otherwise length of branch connecting the tip to the main chain
+	Status    string `json:"status"`    // (string) status of the chain, "active" for the main chain
+}
+
+func (bitcoinRpc BitcoinRpc) GetChainTips() (tips []ChainTip, err error) {
+	return bitcoinRpc.GetChainTipsCtx(context.Background())
+}
+
+func (bitcoinRpc BitcoinRpc) GetChainTipsCtx(ctx context.Context) (tips []ChainTip, err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "getchaintips"
+	jsonRpcInfo["params"] = []interface{}{}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultGetChainTips struct {
+		Tips []ChainTip `json:"result"`
+	}
+	result := resultGetChainTips{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	tips = result.Tips
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetDifficulty() (difficulty float64, err error) {
+	return bitcoinRpc.GetDifficultyCtx(context.Background())
+}
+
+func (bitcoinRpc BitcoinRpc) GetDifficultyCtx(ctx context.Context) (difficulty float64, err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "getdifficulty"
+	jsonRpcInfo["params"] = []interface{}{}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultGetDifficulty struct {
+		Difficulty float64 `json:"result"`
+	}
+	result := resultGetDifficulty{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	difficulty = result.Difficulty
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) WaitForSync(interval time.Duration) (info BlockchainInfo, err error) {
+	return bitcoinRpc.WaitForSyncCtx(context.Background(), interval)
+}
+
+// WaitForSyncCtx polls getblockchaininfo every interval until the node is
+// synced (see BlockchainInfo.IsSynced) and returns the last info. Transient
+// errors (see IsRetryable), e.g. of a node still warming up, don't end the
+// wait; other errors do.
+func (bitcoinRpc BitcoinRpc) WaitForSyncCtx(ctx context.Context, interval time.Duration) (info BlockchainInfo, err error) {
+
+	for {
+		polled, errPoll := bitcoinRpc.GetBlockchainInfoCtx(ctx)
+		if errPoll != nil && !IsRetryable(errPoll) {
+			err = fmt.Errorf("@bitcoinRpc.GetBlockchainInfoCtx(ctx): %w", errPoll)
+			return
+		}
+		if errPoll == nil {
+			info = polled
+			if info.IsSynced() {
+				return
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if errPoll != nil {
+				err = fmt.Errorf("%w (last poll: %v)", ctx.Err(), errPoll)
+				return
+			}
+			err = fmt.Errorf("%w (blocks %d of %d headers, initialblockdownload %v)", ctx.Err(), info.Blocks, info.Headers, info.InitialBlockDownload)
+			return
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+func TestGetBlockchainInfo(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	info, err := bitcoinRpc.GetBlockchainInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Chain != "test" || info.Blocks != bitcoindtest.FixtureBlockCount || !info.Pruned || info.PruneHeight != 2319810 || info.Warnings != nil || !info.IsSynced() {
+		t.Fatalf("unexpected info %+v", info)
+	}
+
+	tips, err := bitcoinRpc.GetChainTips()
+	if err != nil || len(tips) != 2 || tips[0].Status != ChainTipActive || tips[1].Status != ChainTipValidFork || tips[1].BranchLen != 1 {
+		t.Fatalf("unexpected tips %+v: %v", tips, err)
+	}
+
+	difficulty, err := bitcoinRpc.GetDifficulty()
+	if err != nil || difficulty != 10241079.01843548 {
+		t.Fatalf("unexpected difficulty %v: %v", difficulty, err)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+
+	for data, expected := range map[string]int{`""`: 0, `"Unknown new rules activated"`: 1, `[]`: 0, `["a", "b"]`: 2} {
+		warnings := Warnings{}
+		if err := json.Unmarshal([]byte(data), &warnings); err != nil || len(warnings) != expected {
+			t.Fatalf("%s: unexpected warnings %q: %v", data, warnings, err)
+		}
+	}
+}
+
+func TestWaitForSync(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	polls := 0
+	server.Handle("getblockchaininfo", func(request bitcoindtest.Request) (interface{}, *bitcoindtest.Error) {
+		polls++
+		if polls == 1 {
+			return nil, &bitcoindtest.Error{Code: -28, Message: "Loading block index..."}
+		}
+		info := map[string]interface{}{"chain": "regtest", "blocks": 100, "headers": 102, "initialblockdownload": polls < 3}
+		if polls >= 4 {
+			info["blocks"] = 102
+		}
+		return info, nil
+	})
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	info, err := bitcoinRpc.WaitForSync(time.Millisecond)
+	if err != nil || polls != 4 || info.Blocks != 102 {
+		t.Fatalf("unexpected info %+v after %d polls: %v", info, polls, err)
+	}
+
+	server.SetResult("getblockchaininfo", map[string]interface{}{"blocks": 100, "headers": 102, "initialblockdownload": true})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = bitcoinRpc.WaitForSyncCtx(ctx, 5*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// warming up until the deadline
+	server.SetError("getblockchaininfo", -28, "Loading block index...")
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = bitcoinRpc.WaitForSyncCtx(ctx, 5*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	server.SetError("getblockchaininfo", -1, "unexpected")
+	if _, err = bitcoinRpc.WaitForSync(time.Millisecond); err == nil || errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the error of getblockchaininfo, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package gobitcoinclilight
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultHttpClient is shared by every BitcoinRpc built without NewClient, so
+// plain struct literals still reuse keep-alive connections.
+var defaultHttpClient = &http.Client{}
+
+type clientConfig struct {
+	bitcoinRpc   BitcoinRpc
+	tlsConfig    *tls.Config
+	pins         map[string]bool // hex sha256 fingerprints of WithCertificatePins
+	proxyURL     *url.URL
+	roundTripper http.RoundTripper
+	httpClient   *http.Client
+}
+
+type ClientOption func(config *clientConfig) (err error)
+
+// NewClient builds a BitcoinRpc holding its own http.Client, shared by every copy
+// of the returned value. Without options it targets http://127.0.0.1:8332/.
+func NewClient(opts ...ClientOption) (bitcoinRpc BitcoinRpc, err error) {
+
+	config := clientConfig{
+		bitcoinRpc: BitcoinRpc{
+			RpcConnect: "127.0.0.1",
+			RpcPort:    "8332",
+		},
+	}
+	for _, opt := range opts {
+		err = opt(&config)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(config.pins) > 0 {
+		tlsConfig := &tls.Config{}
+		if config.tlsConfig != nil {
+			tlsConfig = config.tlsConfig.Clone()
+		}
+		pins, rootCAs := config.pins, tlsConfig.RootCAs
+		// the chain is verified by verifyPinned instead
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			return verifyPinned(state, pins, rootCAs)
+		}
+		config.tlsConfig = tlsConfig
+	}
+
+	bitcoinRpc = config.bitcoinRpc
+	if config.tlsConfig != nil && bitcoinRpc.RpcScheme == "" {
+		bitcoinRpc.RpcScheme = "https"
+	}
+
+	if config.httpClient != nil {
+		bitcoinRpc.httpClient = config.httpClient
+		return
+	}
+
+	roundTripper := config.roundTripper
+	if roundTripper == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if config.tlsConfig != nil {
+			transport.TLSClientConfig = config.tlsConfig
+		}
+		if config.proxyURL != nil {
+			transport.Proxy = http.ProxyURL(config.proxyURL)
+		}
+		roundTripper = transport
+	}
+	bitcoinRpc.httpClient = &http.Client{Transport: roundTripper}
+
+	return
+}
+
+func WithHost(rpcConnect string, rpcPort string) ClientOption {
+	return func(config *clientConfig) (err error) {
+		config.bitcoinRpc.RpcConnect = rpcConnect
+		config.bitcoinRpc.RpcPort = rpcPort
+		return
+	}
+}
+
+func WithCredentials(rpcUser string, rpcPW string) ClientOption {
+	return func(config *clientConfig) (err error) {
+		config.bitcoinRpc.RpcUser = rpcUser
+		config.bitcoinRpc.RpcPW = rpcPW
+		return
+	}
+}
+
+func WithPath(rpcPath string) ClientOption {
+	return func(config *clientConfig) (err error) {
+		config.bitcoinRpc.RpcPath = strings.TrimPrefix(rpcPath, "/")
+		return
+	}
+}
+
+func WithWallet(walletName string) ClientOption {
+	return WithPath(fmt.Sprintf("wallet/%s", walletName))
+}
+
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(config *clientConfig) (err error) {
+		config.bitcoinRpc.RpcTimeout = timeout
+		return
+	}
+}
+
+// WithTLS talks https to the node (e.g. bitcoind behind an HTTPS reverse proxy).
+// tlsConfig may be nil to use the system roots. A copy of tlsConfig is used,
+// keeping the CA certificates of WithCACertPEM whatever the order of the options.
+func WithTLS(tlsConfig *tls.Config) ClientOption {
+	return func(config *clientConfig) (err error) {
+		merged := &tls.Config{}
+		if tlsConfig != nil {
+			merged = tlsConfig.Clone()
+		}
+		if config.tlsConfig != nil && config.tlsConfig.RootCAs != nil {
+			merged.RootCAs = config.tlsConfig.RootCAs
+		}
+		config.tlsConfig = merged
+		config.bitcoinRpc.RpcScheme = "https"
+		return
+	}
+}
+
+// WithCACertPEM trusts only the PEM encoded CA certificates in caPEM.
+func WithCACertPEM(caPEM []byte) ClientOption {
+	return func(config *clientConfig) (err error) {
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caPEM) {
+			err = fmt.Errorf("certPool.AppendCertsFromPEM(caPEM): no certificate found")
+			return
+		}
+		if config.tlsConfig == nil {
+			config.tlsConfig = &tls.Config{}
+		}
+		config.tlsConfig.RootCAs = certPool
+		config.bitcoinRpc.RpcScheme = "https"
+		return
+	}
+}
+
+// WithCertificatePins accepts the server only if its leaf certificate has one
+// of the given hex SHA-256 fingerprints (of the DER certificate), or if the leaf
+// chains up to a pinned CA certificate presented by the server. Pinning replaces
+// the verification against the system roots, so self-signed certificates work;
+// the CA certificates of WithCACertPEM are still required when given.
+func WithCertificatePins(sha256Fingerprints ...string) ClientOption {
+	return func(config *clientConfig) (err error) {
+		if len(sha256Fingerprints) == 0 {
+			err = fmt.Errorf("len(sha256Fingerprints) == 0")
+			return
+		}
+		if config.pins == nil {
+			config.pins = make(map[string]bool)
+		}
+		for _, fingerprint := range sha256Fingerprints {
+			fingerprint = strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+			pinBytes, errDecode := hex.DecodeString(fingerprint)
+			if errDecode != nil || len(pinBytes) != sha256.Size {
+				err = fmt.Errorf("incorrect sha256 fingerprint[%s]", fingerprint)
+				return
+			}
+			config.pins[fingerprint] = true
+		}
+		config.bitcoinRpc.RpcScheme = "https"
+		return
+	}
+}
+
+// verifyPinned checks the chain of the server against rootCAs when not nil,
+// then requires the leaf, or a CA certificate the leaf chains up to, to be
+// pinned. Any certificate being public, the server can't just present a pinned
+// one next to its own leaf.
+func verifyPinned(state tls.ConnectionState, pins map[string]bool, rootCAs *x509.CertPool) (err error) {
+
+	if len(state.PeerCertificates) == 0 {
+		err = fmt.Errorf("no certificate presented by the server")
+		return
+	}
+	leaf := state.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	if rootCAs != nil {
+		_, err = leaf.Verify(x509.VerifyOptions{DNSName: state.ServerName, Roots: rootCAs, Intermediates: intermediates})
+		if err != nil {
+			err = fmt.Errorf("@leaf.Verify(x509.VerifyOptions{...}): %w", err)
+			return
+		}
+	}
+
+	fingerprint := sha256.Sum256(leaf.Raw)
+	if pins[hex.EncodeToString(fingerprint[:])] {
+		return
+	}
+	for _, cert := range state.PeerCertificates[1:] {
+		fingerprint = sha256.Sum256(cert.Raw)
+		if !pins[hex.EncodeToString(fingerprint[:])] {
+			continue
+		}
+		pinnedRoot := x509.NewCertPool()
+		pinnedRoot.AddCert(cert)
+		if _, errVerify := leaf.Verify(x509.VerifyOptions{DNSName: state.ServerName, Roots: pinnedRoot, Intermediates: intermediates}); errVerify == nil {
+			return
+		}
+	}
+	err = fmt.Errorf("no pinned certificate presented by the server")
+	return
+}
+
+// WithProxy routes requests through an http://, https:// or socks5:// proxy,
+// e.g. "socks5://127.0.0.1:9050" for a node reachable over Tor.
+func WithProxy(proxyURL string) ClientOption {
+	return func(config *clientConfig) (err error) {
+		parsedURL, err := url.Parse(proxyURL)
+		if err != nil {
+			err = fmt.Errorf("@url.Parse(proxyURL): %w", err)
+			return
+		}
+		switch parsedURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			err = fmt.Errorf("incorrect proxy scheme[%s]", parsedURL.Scheme)
+			return
+		}
+		config.proxyURL = parsedURL
+		return
+	}
+}
+
+// WithTransport replaces the built transport, so TLS and proxy options are ignored.
+func WithTransport(roundTripper http.RoundTripper) ClientOption {
+	return func(config *clientConfig) (err error) {
+		config.roundTripper = roundTripper
+		return
+	}
+}
+
+// WithHTTPClient uses httpClient as is, so TLS, proxy and transport options are ignored.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(config *clientConfig) (err error) {
+		config.httpClient = httpClient
+		return
+	}
+}
@@ -0,0 +1,216 @@
+package gobitcoinclilight
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingRoundTripper struct {
+	count int64
+	next  http.RoundTripper
+}
+
+func (roundTripper *countingRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	atomic.AddInt64(&roundTripper.count, 1)
+	return roundTripper.next.RoundTrip(request)
+}
+
+func TestNewClient(t *testing.T) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result":2344981,"error":null,"id":"GoBitcoinCliLight"}`)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	bitcoinRpc, err := NewClient(
+		WithHost(serverURL.Hostname(), serverURL.Port()),
+		WithCredentials("ideajoo", "ideajoo123"),
+		WithCACertPEM(caPEM),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockCount, err := bitcoinRpc.GetBlockCount()
+	if err != nil || blockCount != 2344981 {
+		t.Fatalf("unexpected blockCount %d, %v", blockCount, err)
+	}
+
+	fingerprint := sha256.Sum256(server.Certificate().Raw)
+	bitcoinRpc, err = NewClient(
+		WithHost(serverURL.Hostname(), serverURL.Port()),
+		WithCertificatePins(hex.EncodeToString(fingerprint[:])),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = bitcoinRpc.GetBlockCount(); err != nil {
+		t.Fatalf("pinned certificate rejected: %v", err)
+	}
+
+	bitcoinRpc, err = NewClient(
+		WithHost(serverURL.Hostname(), serverURL.Port()),
+		WithCertificatePins(hex.EncodeToString(make([]byte, sha256.Size))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = bitcoinRpc.GetBlockCount(); err == nil {
+		t.Fatalf("expected an error for an unpinned certificate")
+	}
+
+	// WithTLS keeps the pins and CA certificates, in either order
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	for _, options := range [][]ClientOption{
+		{WithCertificatePins(hex.EncodeToString(fingerprint[:])), WithTLS(tlsConfig)},
+		{WithTLS(tlsConfig), WithCertificatePins(hex.EncodeToString(fingerprint[:]))},
+		{WithCACertPEM(caPEM), WithTLS(tlsConfig)},
+		{WithTLS(tlsConfig), WithCACertPEM(caPEM)},
+	} {
+		bitcoinRpc, err = NewClient(append(options, WithHost(serverURL.Hostname(), serverURL.Port()))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = bitcoinRpc.GetBlockCount(); err != nil {
+			t.Fatalf("certificate rejected with WithTLS: %v", err)
+		}
+	}
+	if tlsConfig.VerifyPeerCertificate != nil || tlsConfig.RootCAs != nil || tlsConfig.InsecureSkipVerify {
+		t.Fatalf("the tls.Config of WithTLS was modified")
+	}
+
+	roundTripper := &countingRoundTripper{next: server.Client().Transport}
+	bitcoinRpc, err = NewClient(
+		WithHost(serverURL.Hostname(), serverURL.Port()),
+		WithTLS(nil),
+		WithTransport(roundTripper),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err = bitcoinRpc.GetBlockCount(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if roundTripper.count != 3 {
+		t.Fatalf("expected 3 round trips through the custom transport, got %d", roundTripper.count)
+	}
+
+	if _, err = NewClient(WithProxy("ftp://127.0.0.1:21")); err == nil {
+		t.Fatalf("expected an error for an ftp proxy")
+	}
+}
+
+// newTestCertificate issues a certificate for 127.0.0.1, signed by parent or
+// self-signed when parent is nil.
+func newTestCertificate(t *testing.T, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (cert *x509.Certificate, key *ecdsa.PrivateKey) {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serialNumber, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: fmt.Sprintf("test %d", serialNumber)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if isCA {
+		template.IsCA, template.BasicConstraintsValid = true, true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// newTestTLSServer presents chain, the first certificate being of key.
+func newTestTLSServer(t *testing.T, key *ecdsa.PrivateKey, chain ...*x509.Certificate) (serverURL *url.URL) {
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result":2344981,"error":null,"id":"GoBitcoinCliLight"}`)
+	}))
+	certificate := tls.Certificate{PrivateKey: key}
+	for _, cert := range chain {
+		certificate.Certificate = append(certificate.Certificate, cert.Raw)
+	}
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	serverURL, _ = url.Parse(server.URL)
+	return
+}
+
+func TestCertificatePins(t *testing.T) {
+
+	ca, caKey := newTestCertificate(t, true, nil, nil)
+	leaf, leafKey := newTestCertificate(t, false, ca, caKey)
+	attackerLeaf, attackerKey := newTestCertificate(t, false, nil, nil)
+	pinOf := func(cert *x509.Certificate) string {
+		fingerprint := sha256.Sum256(cert.Raw)
+		return hex.EncodeToString(fingerprint[:])
+	}
+	pemOf := func(cert *x509.Certificate) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+
+	serverURL := newTestTLSServer(t, leafKey, leaf, ca)
+	// a man in the middle presents its own leaf, followed by the pinned certificates
+	attackerURL := newTestTLSServer(t, attackerKey, attackerLeaf, leaf, ca)
+
+	for _, test := range []struct {
+		serverURL *url.URL
+		options   []ClientOption
+		accepted  bool
+	}{
+		{serverURL, []ClientOption{WithCertificatePins(pinOf(leaf))}, true},
+		{serverURL, []ClientOption{WithCertificatePins(pinOf(ca))}, true},
+		{serverURL, []ClientOption{WithCertificatePins(pinOf(attackerLeaf))}, false},
+		{attackerURL, []ClientOption{WithCertificatePins(pinOf(leaf))}, false},
+		{attackerURL, []ClientOption{WithCertificatePins(pinOf(ca))}, false},
+		// the CA certificates of WithCACertPEM are still required
+		{serverURL, []ClientOption{WithCertificatePins(pinOf(leaf)), WithCACertPEM(pemOf(ca))}, true},
+		{serverURL, []ClientOption{WithCACertPEM(pemOf(attackerLeaf)), WithCertificatePins(pinOf(leaf))}, false},
+		{serverURL, []ClientOption{WithCertificatePins(pinOf(leaf)), WithTLS(nil), WithCACertPEM(pemOf(attackerLeaf))}, false},
+	} {
+		bitcoinRpc, err := NewClient(append(test.options, WithHost(test.serverURL.Hostname(), test.serverURL.Port()))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = bitcoinRpc.GetBlockCount(); (err == nil) != test.accepted {
+			t.Fatalf("%s: expected accepted %v, got %v", test.serverURL, test.accepted, err)
+		}
+	}
+}
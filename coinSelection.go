@@ -0,0 +1,400 @@
+package gobitcoinclilight
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// coin selection algorithms, in the order CoinSelector tries them
+const (
+	CoinSelectionBnB          = "bnb"           // branch and bound: an exact match without change
+	CoinSelectionKnapsack     = "knapsack"      // bitcoind's knapsack solver, with change
+	CoinSelectionLargestFirst = "largest-first" // the fewest inputs, when the others exceed MaxInputs
+)
+
+const (
+	bnbMaxTries        = 100000
+	knapsackIterations = 1000
+	dustRelayFeeRate   = FeeRate(3000) // bitcoind's default -dustrelayfee
+)
+
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// CoinSelector picks the unspents which pay for outputs at FeeRate.
+type CoinSelector struct {
+	FeeRate          FeeRate
+	ChangeType       string // script type of the change output, ScriptTypeP2WPKH when empty
+	MinConfirmations int    // unconfirmed unspents are used only when 0 and Safe
+	MinChange        Amount // smaller change goes to the fee; the dust threshold of ChangeType when zero
+	MaxInputs        int    // zero for no limit
+}
+
+type CoinSelection struct {
+	Inputs     []Unspent
+	InputValue Amount // sum of the inputs
+	Payment    Amount // sum of the outputs, without the change
+	Change     Amount // zero without a change output
+	Fee        Amount // InputValue - Payment - Change
+	VSize      int64  // estimated vsize once signed, with the change output if any
+	Algorithm  string
+}
+
+// TxInputs returns the inputs for CreateRawTransactionOrdered.
+func (selection CoinSelection) TxInputs() (txInputs []TxInput) {
+	for _, unspent := range selection.Inputs {
+		txInputs = append(txInputs, TxInput{TxID: unspent.TxID, Vout: unspent.Vout})
+	}
+	return
+}
+
+type coinCandidate struct {
+	unspent        Unspent
+	weight         int64 // of the signed input
+	witness        bool
+	effectiveValue Amount // amount minus the fee of spending it
+}
+
+// changeScriptLength is the length of a scriptPubKey of scriptType.
+func changeScriptLength(scriptType string) (length int, err error) {
+	switch scriptType {
+	case ScriptTypeP2PKH:
+		return 25, nil
+	case ScriptTypeP2SH:
+		return 23, nil
+	case ScriptTypeP2WPKH:
+		return 22, nil
+	case ScriptTypeP2WSH, ScriptTypeP2TR:
+		return 34, nil
+	}
+	err = fmt.Errorf("incorrect change type[%s]", scriptType)
+	return
+}
+
+// DustThreshold is the smallest amount bitcoind relays in an output of
+// scriptType, at the default dust relay fee.
+func DustThreshold(scriptType string) (dust Amount, err error) {
+
+	length, err := changeScriptLength(scriptType)
+	if err != nil {
+		return
+	}
+	size := int64(8 + 1 + length)
+	if scriptType == ScriptTypeP2PKH || scriptType == ScriptTypeP2SH {
+		size += 32 + 4 + 1 + 107 + 4
+	} else {
+		size += 32 + 4 + 1 + 107/witnessScaleFactor + 4
+	}
+	return dustRelayFeeRate.Fee(size), nil
+}
+
+// coinSelectionTx holds what the selection doesn't change: the outputs and the change.
+type coinSelectionTx struct {
+	CoinSelector
+	payment       Amount
+	outputCount   int
+	outputsWeight int64
+	changeWeight  int64
+	minChange     Amount
+}
+
+// weight is the estimated weight of the signed transaction spending selected.
+func (tx coinSelectionTx) weight(selected []coinCandidate, withChange bool) (weight int64) {
+
+	outputCount := tx.outputCount
+	weight = tx.outputsWeight
+	if withChange {
+		outputCount++
+		weight += tx.changeWeight
+	}
+	weight += (4 + compactSizeLength(len(selected)) + compactSizeLength(outputCount) + 4) * witnessScaleFactor
+
+	witness := false
+	for _, candidate := range selected {
+		weight += candidate.weight
+		witness = witness || candidate.witness
+	}
+	if witness {
+		// the segwit marker and flag, and an empty witness for each non witness input
+		weight += 2
+		for _, candidate := range selected {
+			if !candidate.witness {
+				weight++
+			}
+		}
+	}
+	return
+}
+
+// result completes selected with the change, if allowed and at least minChange.
+func (tx coinSelectionTx) result(selected []coinCandidate, algorithm string, allowChange bool) (selection CoinSelection, ok bool) {
+
+	if len(selected) == 0 || (tx.MaxInputs > 0 && len(selected) > tx.MaxInputs) {
+		return
+	}
+	selection.Algorithm = algorithm
+	for _, candidate := range selected {
+		selection.Inputs = append(selection.Inputs, candidate.unspent)
+		selection.InputValue += candidate.unspent.Amount
+	}
+	selection.Payment = tx.payment
+
+	selection.VSize = weightToVSize(tx.weight(selected, true))
+	selection.Change = selection.InputValue - tx.payment - tx.FeeRate.Fee(selection.VSize)
+	if allowChange && selection.Change >= tx.minChange {
+		selection.Fee = selection.InputValue - tx.payment - selection.Change
+		return selection, true
+	}
+
+	selection.VSize = weightToVSize(tx.weight(selected, false))
+	selection.Change = 0
+	selection.Fee = selection.InputValue - tx.payment
+	if selection.Fee < tx.FeeRate.Fee(selection.VSize) {
+		return CoinSelection{}, false
+	}
+	return selection, true
+}
+
+// Select picks among unspents the inputs of a transaction paying outputs,
+// which must not contain the change. It skips the unspents which are not
+// Spendable or Safe, have less than MinConfirmations, have an input size
+// unknown from their descriptor or scriptPubKey, or cost more to spend than
+// they are worth. It tries a changeless branch and bound first, then the
+// knapsack, then largest first.
+func (selector CoinSelector) Select(unspents []Unspent, outputs []TxOutput) (selection CoinSelection, err error) {
+
+	if selector.FeeRate < 0 {
+		err = fmt.Errorf("incorrect FeeRate[%d]", selector.FeeRate)
+		return
+	}
+	if selector.ChangeType == "" {
+		selector.ChangeType = ScriptTypeP2WPKH
+	}
+	tx := coinSelectionTx{CoinSelector: selector, outputCount: len(outputs)}
+
+	if len(outputs) == 0 {
+		err = fmt.Errorf("len(outputs) == 0")
+		return
+	}
+	for index, output := range outputs {
+		if output.Change {
+			err = fmt.Errorf("outputs[%d] is the change", index)
+			return
+		}
+		if output.Amount < 0 || !output.Amount.IsValid() {
+			err = fmt.Errorf("outputs[%d]: incorrect amount[%s]", index, output.Amount)
+			return
+		}
+		scriptPubKey, errScript := output.ScriptPubKey()
+		if errScript != nil {
+			err = fmt.Errorf("outputs[%d]: %v", index, errScript)
+			return
+		}
+		tx.payment += output.Amount
+		tx.outputsWeight += (8 + compactSizeLength(len(scriptPubKey)) + int64(len(scriptPubKey))) * witnessScaleFactor
+	}
+
+	changeLength, err := changeScriptLength(selector.ChangeType)
+	if err != nil {
+		return
+	}
+	tx.changeWeight = int64(8+1+changeLength) * witnessScaleFactor
+	tx.minChange = selector.MinChange
+	if tx.minChange <= 0 {
+		if tx.minChange, err = DustThreshold(selector.ChangeType); err != nil {
+			return
+		}
+	}
+
+	candidates := []coinCandidate{}
+	available := Amount(0)
+	for _, unspent := range unspents {
+		if !unspent.Spendable || !unspent.Safe || unspent.Confirmations < selector.MinConfirmations {
+			continue
+		}
+		weight, witness, errWeight := unspentInputWeight(unspent)
+		if errWeight != nil {
+			continue
+		}
+		effectiveValue := unspent.Amount - Amount((int64(selector.FeeRate)*weight+3999)/4000)
+		if effectiveValue <= 0 {
+			continue
+		}
+		candidates = append(candidates, coinCandidate{unspent: unspent, weight: weight, witness: witness, effectiveValue: effectiveValue})
+		available += effectiveValue
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].effectiveValue > candidates[j].effectiveValue })
+
+	// the fee of the transaction without inputs, with a vbyte to spare for the rounding of the inputs
+	baseFee := selector.FeeRate.Fee(weightToVSize(tx.weight(nil, false)+2) + 1)
+	changeFee := selector.FeeRate.Fee(weightToVSize(tx.changeWeight))
+	target := tx.payment + baseFee
+
+	// spending the change later costs about an input of its type
+	changeSpendWeight, _, _ := inputWeight(selector.ChangeType)
+	costOfChange := changeFee + selector.FeeRate.Fee(weightToVSize(changeSpendWeight))
+
+	if selected := selectBnB(candidates, target, costOfChange, selector.MaxInputs); selected != nil {
+		if selection, ok := tx.result(selected, CoinSelectionBnB, false); ok {
+			return selection, nil
+		}
+	}
+	if selected := selectKnapsack(candidates, target+changeFee, tx.minChange); selected != nil {
+		if selection, ok := tx.result(selected, CoinSelectionKnapsack, true); ok {
+			return selection, nil
+		}
+	}
+	for count := 1; count <= len(candidates); count++ {
+		if selection, ok := tx.result(candidates[:count], CoinSelectionLargestFirst, true); ok {
+			return selection, nil
+		}
+	}
+
+	err = fmt.Errorf("%w: %d spendable unspents worth %s after fees, for %s", ErrInsufficientFunds, len(candidates), available, target)
+	return
+}
+
+// selectBnB searches, as bitcoind's SelectCoinsBnB, the inputs worth target up
+// to costOfChange more, with the least excess. candidates are sorted by
+// effective value, descending.
+func selectBnB(candidates []coinCandidate, target Amount, costOfChange Amount, maxInputs int) (selected []coinCandidate) {
+
+	available := Amount(0)
+	for _, candidate := range candidates {
+		available += candidate.effectiveValue
+	}
+	if available < target {
+		return
+	}
+
+	value, included := Amount(0), []int{}
+	best, bestExcess := []int(nil), Amount(0)
+	for tries, index := 0, 0; tries < bnbMaxTries; tries, index = tries+1, index+1 {
+		backtrack := false
+		if value+available < target || value > target+costOfChange || (maxInputs > 0 && len(included) > maxInputs) {
+			backtrack = true
+		} else if value >= target {
+			if excess := value - target; best == nil || excess < bestExcess {
+				best, bestExcess = append([]int{}, included...), excess
+			}
+			backtrack = true
+		}
+
+		if backtrack {
+			if len(included) == 0 {
+				break
+			}
+			// give back the omitted candidates, then omit the last included one
+			last := included[len(included)-1]
+			for index--; index > last; index-- {
+				available += candidates[index].effectiveValue
+			}
+			value -= candidates[last].effectiveValue
+			included = included[:len(included)-1]
+			continue
+		}
+
+		candidate := candidates[index]
+		available -= candidate.effectiveValue
+		// omitting a candidate then including an equal one is the same branch
+		if len(included) == 0 || index-1 == included[len(included)-1] || candidate.effectiveValue != candidates[index-1].effectiveValue {
+			included = append(included, index)
+			value += candidate.effectiveValue
+		}
+	}
+
+	for _, index := range best {
+		selected = append(selected, candidates[index])
+	}
+	return
+}
+
+// selectKnapsack is bitcoind's KnapsackSolver: a single candidate matching
+// target, or the smaller candidates approximating target (or target plus
+// minChange), or the smallest larger candidate, whichever is closer.
+func selectKnapsack(candidates []coinCandidate, target Amount, minChange Amount) (selected []coinCandidate) {
+
+	shuffled := append([]coinCandidate{}, candidates...)
+	rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	lower, totalLower := []coinCandidate{}, Amount(0)
+	var lowestLarger *coinCandidate
+	for i := range shuffled {
+		candidate := shuffled[i]
+		switch {
+		case candidate.effectiveValue == target:
+			return []coinCandidate{candidate}
+		case candidate.effectiveValue < target+minChange:
+			lower = append(lower, candidate)
+			totalLower += candidate.effectiveValue
+		case lowestLarger == nil || candidate.effectiveValue < lowestLarger.effectiveValue:
+			lowestLarger = &shuffled[i]
+		}
+	}
+
+	if totalLower == target {
+		return lower
+	}
+	if totalLower < target {
+		if lowestLarger == nil {
+			return nil
+		}
+		return []coinCandidate{*lowestLarger}
+	}
+
+	sort.SliceStable(lower, func(i, j int) bool { return lower[i].effectiveValue > lower[j].effectiveValue })
+	best, bestValue := approximateBestSubset(lower, totalLower, target)
+	if bestValue != target && totalLower >= target+minChange {
+		best, bestValue = approximateBestSubset(lower, totalLower, target+minChange)
+	}
+
+	if lowestLarger != nil && ((bestValue != target && bestValue < target+minChange) || lowestLarger.effectiveValue <= bestValue) {
+		return []coinCandidate{*lowestLarger}
+	}
+	for index, included := range best {
+		if included {
+			selected = append(selected, lower[index])
+		}
+	}
+	return
+}
+
+// approximateBestSubset randomly includes candidates, then the rest, until
+// target is reached, and keeps the smallest total reaching it.
+func approximateBestSubset(candidates []coinCandidate, total Amount, target Amount) (best []bool, bestValue Amount) {
+
+	best = make([]bool, len(candidates))
+	for index := range best {
+		best[index] = true
+	}
+	bestValue = total
+
+	included := make([]bool, len(candidates))
+	for iteration := 0; iteration < knapsackIterations && bestValue != target; iteration++ {
+		for index := range included {
+			included[index] = false
+		}
+		value, reached := Amount(0), false
+		for pass := 0; pass < 2 && !reached; pass++ {
+			for index, candidate := range candidates {
+				// the first pass picks at random, the second the rest
+				if (pass == 0 && rand.Intn(2) == 0) || (pass == 1 && included[index]) {
+					continue
+				}
+				value += candidate.effectiveValue
+				included[index] = true
+				if value >= target {
+					reached = true
+					if value < bestValue {
+						bestValue = value
+						copy(best, included)
+					}
+					value -= candidate.effectiveValue
+					included[index] = false
+				}
+			}
+		}
+	}
+	return
+}
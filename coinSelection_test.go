@@ -0,0 +1,126 @@
+package gobitcoinclilight
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+// p2wpkhUnspents are confirmed spendable unspents of FixtureAddress; a P2WPKH
+// input is 68 vbytes, the P2WPKH payment 31 and the change 31.
+func p2wpkhUnspents(amounts ...Amount) (unspents []Unspent) {
+	for index, amount := range amounts {
+		unspents = append(unspents, Unspent{
+			TxID:          fmt.Sprintf("%064x", index+1),
+			Vout:          index,
+			Address:       bitcoindtest.FixtureAddress,
+			ScriptPubKey:  "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c",
+			Amount:        amount,
+			Confirmations: 6,
+			Spendable:     true,
+			Solvable:      true,
+			Safe:          true,
+		})
+	}
+	return
+}
+
+func TestCoinSelection(t *testing.T) {
+
+	selector := CoinSelector{FeeRate: 1000}
+	payment := func(amount Amount) []TxOutput {
+		return []TxOutput{{Address: bitcoindtest.FixtureAddress, Amount: amount}}
+	}
+
+	for _, test := range []struct {
+		name      string
+		selector  CoinSelector
+		unspents  []Unspent
+		payment   Amount
+		algorithm string
+		inputs    []Amount
+		change    Amount
+		fee       Amount
+		vsize     int64
+	}{
+		// 10120 minus its 68 sat of fee is within the cost of a change of 10000 + 43
+		{"bnb", selector, p2wpkhUnspents(50000, 10120, 30000), 10000, CoinSelectionBnB, []Amount{10120}, 0, 120, 110},
+		{"knapsack", selector, p2wpkhUnspents(50000, 30000, 20000), 60000, CoinSelectionKnapsack, []Amount{50000, 20000}, 9791, 209, 209},
+		// a change of 259 is dust, it goes to the fee
+		{"dust change", selector, p2wpkhUnspents(10400), 10000, CoinSelectionKnapsack, []Amount{10400}, 0, 400, 110},
+		// the knapsack needs 3 inputs
+		{"largest first", CoinSelector{FeeRate: 1000, MaxInputs: 2}, p2wpkhUnspents(30000, 20000, 20000, 5000), 40000, CoinSelectionLargestFirst, []Amount{30000, 20000}, 9791, 209, 209},
+		// the change of 9791 with 50000 and 20000 is too small
+		{"min change", CoinSelector{FeeRate: 1000, MinChange: 10000}, p2wpkhUnspents(50000, 30000, 20000), 60000, CoinSelectionKnapsack, []Amount{50000, 30000}, 19791, 209, 209},
+	} {
+		selection, err := test.selector.Select(test.unspents, payment(test.payment))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		inputs := []Amount{}
+		for _, input := range selection.Inputs {
+			inputs = append(inputs, input.Amount)
+		}
+		if selection.Algorithm != test.algorithm || fmt.Sprint(inputs) != fmt.Sprint(test.inputs) || selection.Change != test.change || selection.Fee != test.fee || selection.VSize != test.vsize {
+			t.Fatalf("%s: unexpected selection %s %v change %d fee %d vsize %d", test.name, selection.Algorithm, inputs, selection.Change, selection.Fee, selection.VSize)
+		}
+		if selection.InputValue != selection.Payment+selection.Change+selection.Fee || selection.Fee < FeeRate(1000).Fee(selection.VSize) {
+			t.Fatalf("%s: inconsistent selection %+v", test.name, selection)
+		}
+		if txInputs := selection.TxInputs(); len(txInputs) != len(selection.Inputs) || txInputs[0].TxID != selection.Inputs[0].TxID {
+			t.Fatalf("%s: unexpected inputs %+v", test.name, txInputs)
+		}
+	}
+}
+
+func TestCoinSelectionFilters(t *testing.T) {
+
+	unspents := p2wpkhUnspents(20000, 20000, 20000, 20000, 20000, 50)
+	unspents[0].Spendable = false
+	unspents[1].Safe = false
+	unspents[2].Confirmations = 0
+	unspents[3].ScriptPubKey = "a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87" // P2SH of unknown size
+	// only unspents[4] is usable, unspents[5] costs more than it is worth
+	outputs := []TxOutput{{Address: bitcoindtest.FixtureAddress, Amount: 15000}}
+
+	selection, err := CoinSelector{FeeRate: 1000, MinConfirmations: 1}.Select(unspents, outputs)
+	if err != nil || len(selection.Inputs) != 1 || selection.Inputs[0].TxID != unspents[4].TxID {
+		t.Fatalf("unexpected selection %+v: %v", selection, err)
+	}
+
+	outputs[0].Amount = 25000
+	if _, err = (CoinSelector{FeeRate: 1000, MinConfirmations: 1}).Select(unspents, outputs); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected ErrInsufficientFunds, got %v", err)
+	}
+	// with its descriptor, the P2SH unspent is P2SH-P2WPKH
+	unspents[3].Desc = "sh(wpkh([3938a2e2/0h/0h/1h]0307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52))#abcdefgh"
+	if selection, err = (CoinSelector{FeeRate: 1000, MinConfirmations: 1}).Select(unspents, outputs); err != nil || len(selection.Inputs) != 2 {
+		t.Fatalf("unexpected selection %+v: %v", selection, err)
+	}
+
+	for _, test := range []struct {
+		selector CoinSelector
+		outputs  []TxOutput
+	}{
+		{CoinSelector{FeeRate: -1}, outputs},
+		{CoinSelector{FeeRate: 1000}, nil},
+		{CoinSelector{FeeRate: 1000}, []TxOutput{{Address: bitcoindtest.FixtureAddress, Amount: 1000, Change: true}}},
+		{CoinSelector{FeeRate: 1000}, []TxOutput{{Address: "tb1qincorrect", Amount: 1000}}},
+		{CoinSelector{FeeRate: 1000, ChangeType: ScriptTypeNullData}, outputs},
+	} {
+		if _, err = test.selector.Select(unspents, test.outputs); err == nil || errors.Is(err, ErrInsufficientFunds) {
+			t.Fatalf("%+v %+v: expected an error, got %v", test.selector, test.outputs, err)
+		}
+	}
+}
+
+func TestDustThreshold(t *testing.T) {
+
+	for scriptType, expected := range map[string]Amount{ScriptTypeP2PKH: 546, ScriptTypeP2SH: 540, ScriptTypeP2WPKH: 294, ScriptTypeP2WSH: 330, ScriptTypeP2TR: 330} {
+		if dust, err := DustThreshold(scriptType); err != nil || dust != expected {
+			t.Fatalf("%s: unexpected dust %d: %v", scriptType, dust, err)
+		}
+	}
+}
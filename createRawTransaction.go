@@ -0,0 +1,381 @@
+package gobitcoinclilight
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+type TxInput struct {
+	TxID     string  `json:"txid"`
+	Vout     int     `json:"vout"`
+	Sequence *uint32 `json:"sequence,omitempty"` // nil lets bitcoind pick it from locktime and replaceable
+}
+
+const (
+	SequenceFinal       uint32 = 0xffffffff
+	SequenceMaxNonRBF   uint32 = 0xfffffffe // the highest sequence allowing locktime without signalling RBF
+	SequenceRBF         uint32 = 0xfffffffd // what bitcoind uses for replaceable transactions
+	MaxLockTimeAsHeight uint32 = 500000000  // lower locktimes are block heights, higher ones UNIX times
+)
+
+// Validate checks that txInput is a well-formed outpoint.
+func (txInput TxInput) Validate() (err error) {
+
+	if len(txInput.TxID) != 64 {
+		err = fmt.Errorf("incorrect txid[%s]: length %d", txInput.TxID, len(txInput.TxID))
+		return
+	}
+	if _, errDecode := hex.DecodeString(txInput.TxID); errDecode != nil {
+		err = fmt.Errorf("incorrect txid[%s]: %v", txInput.TxID, errDecode)
+		return
+	}
+	if txInput.Vout < 0 || int64(txInput.Vout) > math.MaxUint32 {
+		err = fmt.Errorf("incorrect vout[%d]", txInput.Vout)
+		return
+	}
+	return
+}
+
+// txInputFromMap converts the {"txid", "vout", "sequence"} maps of CreateRawTransaction.
+func txInputFromMap(inTxUnspent map[string]interface{}) (txInput TxInput, err error) {
+
+	txID, ok := inTxUnspent["txid"].(string)
+	if !ok {
+		err = fmt.Errorf("txid[%v] is not a string", inTxUnspent["txid"])
+		return
+	}
+	vout, err := mapInteger(inTxUnspent["vout"])
+	if err != nil {
+		err = fmt.Errorf("vout: %v", err)
+		return
+	}
+	txInput = TxInput{TxID: txID, Vout: int(vout)}
+
+	if value, ok := inTxUnspent["sequence"]; ok {
+		sequence, errSequence := mapInteger(value)
+		if errSequence != nil || sequence < 0 || sequence > math.MaxUint32 {
+			err = fmt.Errorf("incorrect sequence[%v]", value)
+			return
+		}
+		sequence32 := uint32(sequence)
+		txInput.Sequence = &sequence32
+	}
+
+	err = txInput.Validate()
+	return
+}
+
+func mapInteger(value interface{}) (integer int64, err error) {
+
+	switch number := value.(type) {
+	case int:
+		integer = int64(number)
+	case int32:
+		integer = int64(number)
+	case int64:
+		integer = number
+	case uint32:
+		integer = int64(number)
+	case float64:
+		if number != math.Trunc(number) || math.Abs(number) > math.MaxUint32 {
+			err = fmt.Errorf("%v is not an integer", value)
+			return
+		}
+		integer = int64(number)
+	case json.Number:
+		integer, err = number.Int64()
+	default:
+		err = fmt.Errorf("%v is not an integer", value)
+	}
+	return
+}
+
+// TxOutput pays Amount to Address, or is the OP_RETURN output carrying Data
+// (hex) when Address is empty. Change marks the change output, whose vout is
+// reported back in CreatedRawTransaction.ChangeVout.
+type TxOutput struct {
+	Address string
+	Amount  Amount
+	Data    string
+	Change  bool
+}
+
+func (txOutput TxOutput) isData() bool {
+	return txOutput.Address == "" && txOutput.Data != ""
+}
+
+func (txOutput TxOutput) param() map[string]interface{} {
+	if txOutput.isData() {
+		return map[string]interface{}{"data": txOutput.Data}
+	}
+	return map[string]interface{}{txOutput.Address: txOutput.Amount}
+}
+
+// ScriptPubKey returns the script txOutput pays to.
+func (txOutput TxOutput) ScriptPubKey() (scriptPubKey []byte, err error) {
+
+	if !txOutput.isData() {
+		return AddressScriptPubKey(txOutput.Address)
+	}
+
+	data, err := hex.DecodeString(txOutput.Data)
+	if err != nil {
+		err = fmt.Errorf("@hex.DecodeString(txOutput.Data): %v", err)
+		return
+	}
+	scriptPubKey = append([]byte{opReturn}, pushData(data)...)
+	return
+}
+
+// pushData is the minimal push of data, as bitcoind's CScript << data.
+func pushData(data []byte) (script []byte) {
+
+	switch {
+	case len(data) <= 75:
+		script = []byte{byte(len(data))}
+	case len(data) <= 0xff:
+		script = []byte{0x4c, byte(len(data))}
+	case len(data) <= 0xffff:
+		script = []byte{0x4d, byte(len(data)), byte(len(data) >> 8)}
+	default:
+		script = []byte{0x4e, byte(len(data)), byte(len(data) >> 8), byte(len(data) >> 16), byte(len(data) >> 24)}
+	}
+	return append(script, data...)
+}
+
+type CreateRawTransactionOptions struct {
+	BIP69       bool   // sort inputs and outputs lexicographically (BIP69) instead of keeping the given order
+	LockTime    uint32 // block height, or UNIX time from MaxLockTimeAsHeight; non-0 makes bitcoind set non-final sequences
+	Replaceable bool   // signal BIP125 replaceability on the inputs without Sequence; always sent, bitcoind's default is true since v24
+}
+
+type CreatedRawTransaction struct {
+	Hex        string
+	Inputs     []TxInput  // in the order of the transaction
+	Outputs    []TxOutput // in the order of the transaction, Outputs[n] is vout n
+	ChangeVout int        // vout of the output marked as Change, -1 without one
+}
+
+// Vout returns the vout of the first output paying to address, or -1.
+func (created CreatedRawTransaction) Vout(address string) int {
+	for vout, txOutput := range created.Outputs {
+		if txOutput.Address == address {
+			return vout
+		}
+	}
+	return -1
+}
+
+var ErrTransactionMismatch = errors.New("transaction does not match")
+
+// Verify decodes created.Hex and checks that it spends created.Inputs and pays
+// created.Outputs, in order, before the transaction gets signed.
+func (created CreatedRawTransaction) Verify() (tx Transaction, err error) {
+
+	tx, err = DecodeTransactionHex(created.Hex)
+	if err != nil {
+		err = fmt.Errorf("@DecodeTransactionHex(created.Hex): %v", err)
+		return
+	}
+
+	if len(tx.Inputs) != len(created.Inputs) {
+		err = fmt.Errorf("%w: %d inputs instead of %d", ErrTransactionMismatch, len(tx.Inputs), len(created.Inputs))
+		return
+	}
+	for i, txInput := range created.Inputs {
+		input := tx.Inputs[i]
+		if !strings.EqualFold(input.PrevTxID.String(), txInput.TxID) || int(input.PrevVout) != txInput.Vout {
+			err = fmt.Errorf("%w: inputs[%d] spends %s:%d instead of %s:%d", ErrTransactionMismatch, i, input.PrevTxID, input.PrevVout, txInput.TxID, txInput.Vout)
+			return
+		}
+		if txInput.Sequence != nil && input.Sequence != *txInput.Sequence {
+			err = fmt.Errorf("%w: inputs[%d] sequence %d instead of %d", ErrTransactionMismatch, i, input.Sequence, *txInput.Sequence)
+			return
+		}
+	}
+
+	if len(tx.Outputs) != len(created.Outputs) {
+		err = fmt.Errorf("%w: %d outputs instead of %d", ErrTransactionMismatch, len(tx.Outputs), len(created.Outputs))
+		return
+	}
+	for vout, txOutput := range created.Outputs {
+		scriptPubKey, errScript := txOutput.ScriptPubKey()
+		if errScript != nil {
+			err = fmt.Errorf("@created.Outputs[%d].ScriptPubKey(): %v", vout, errScript)
+			return
+		}
+		output := tx.Outputs[vout]
+		if !bytes.Equal(output.ScriptPubKey, scriptPubKey) || (!txOutput.isData() && output.Value != txOutput.Amount) {
+			err = fmt.Errorf("%w: vout %d pays %s to %x instead of %s to %x", ErrTransactionMismatch, vout, output.Value, output.ScriptPubKey, txOutput.Amount, scriptPubKey)
+			return
+		}
+	}
+	return
+}
+
+// SortBIP69 sorts inputs by txid, whatever its case, then vout, and outputs by amount then scriptPubKey.
+func SortBIP69(inputs []TxInput, outputs []TxOutput) (err error) {
+
+	scriptPubKeys := make([][]byte, len(outputs))
+	for i := range outputs {
+		scriptPubKeys[i], err = outputs[i].ScriptPubKey()
+		if err != nil {
+			err = fmt.Errorf("@outputs[%d].ScriptPubKey(): %v", i, err)
+			return
+		}
+	}
+
+	sort.SliceStable(inputs, func(i, j int) bool {
+		txIDI, txIDJ := strings.ToLower(inputs[i].TxID), strings.ToLower(inputs[j].TxID)
+		if txIDI != txIDJ {
+			return txIDI < txIDJ
+		}
+		return inputs[i].Vout < inputs[j].Vout
+	})
+
+	indexes := make([]int, len(outputs))
+	for i := range outputs {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := outputs[indexes[i]], outputs[indexes[j]]
+		if a.Amount != b.Amount {
+			return a.Amount < b.Amount
+		}
+		return bytes.Compare(scriptPubKeys[indexes[i]], scriptPubKeys[indexes[j]]) < 0
+	})
+
+	sorted := make([]TxOutput, len(outputs))
+	for i, index := range indexes {
+		sorted[i] = outputs[index]
+	}
+	copy(outputs, sorted)
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) CreateRawTransactionOrdered(inputs []TxInput, outputs []TxOutput, options CreateRawTransactionOptions) (created CreatedRawTransaction, err error) {
+	return bitcoinRpc.CreateRawTransactionOrderedCtx(context.Background(), inputs, outputs, options)
+}
+
+// CreateRawTransactionOrderedCtx creates a raw transaction whose outputs are in
+// the order of outputs (or BIP69 order), so the same arguments always give the
+// same transaction.
+func (bitcoinRpc BitcoinRpc) CreateRawTransactionOrderedCtx(ctx context.Context, inputs []TxInput, outputs []TxOutput, options CreateRawTransactionOptions) (created CreatedRawTransaction, err error) {
+
+	if len(outputs) == 0 {
+		err = fmt.Errorf("len(outputs) == 0")
+		return
+	}
+
+	outpoints := make(map[TxInput]bool)
+	for i, txInput := range inputs {
+		err = txInput.Validate()
+		if err != nil {
+			err = fmt.Errorf("inputs[%d]: %v", i, err)
+			return
+		}
+		outpoint := TxInput{TxID: txInput.TxID, Vout: txInput.Vout}
+		if outpoints[outpoint] {
+			err = fmt.Errorf("inputs[%d]: duplicated outpoint %s:%d", i, txInput.TxID, txInput.Vout)
+			return
+		}
+		outpoints[outpoint] = true
+
+		// same check as bitcoind, which would otherwise reject the whole call
+		if options.Replaceable && txInput.Sequence != nil && *txInput.Sequence > SequenceRBF {
+			err = fmt.Errorf("inputs[%d]: sequence %d contradicts Replaceable", i, *txInput.Sequence)
+			return
+		}
+	}
+
+	created.Inputs = append(make([]TxInput, 0, len(inputs)), inputs...)
+	created.Outputs = append(make([]TxOutput, 0, len(outputs)), outputs...)
+	created.ChangeVout = -1
+
+	dataOutputs := 0
+	for i, txOutput := range created.Outputs {
+		switch {
+		case txOutput.isData():
+			dataOutputs++
+			if txOutput.Change {
+				err = fmt.Errorf("outputs[%d]: a data output can not be change", i)
+				return
+			}
+		case txOutput.Address == "":
+			err = fmt.Errorf("outputs[%d]: neither Address nor Data", i)
+			return
+		case txOutput.Amount < 0 || !txOutput.Amount.IsValid():
+			err = fmt.Errorf("outputs[%d]: incorrect amount %d", i, txOutput.Amount)
+			return
+		}
+		if txOutput.Change {
+			if created.ChangeVout >= 0 {
+				err = fmt.Errorf("outputs[%d]: more than one change output", i)
+				return
+			}
+			created.ChangeVout = i
+		}
+	}
+	if dataOutputs > 1 {
+		err = fmt.Errorf("more than one data output")
+		return
+	}
+
+	if options.BIP69 {
+		err = SortBIP69(created.Inputs, created.Outputs)
+		if err != nil {
+			err = fmt.Errorf("@SortBIP69(created.Inputs, created.Outputs): %v", err)
+			return
+		}
+		created.ChangeVout = -1
+		for vout, txOutput := range created.Outputs {
+			if txOutput.Change {
+				created.ChangeVout = vout
+			}
+		}
+	}
+
+	outputParams := make([]map[string]interface{}, 0, len(created.Outputs))
+	for _, txOutput := range created.Outputs {
+		outputParams = append(outputParams, txOutput.param())
+	}
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "createrawtransaction"
+	jsonRpcInfo["params"] = []interface{}{created.Inputs, outputParams, options.LockTime, options.Replaceable}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultCreateRawTx struct {
+		RawTx string `json:"result"`
+	}
+	result := resultCreateRawTx{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+	if result.RawTx == "" {
+		err = fmt.Errorf("rawTx == '': rawTx of result is empty")
+		return
+	}
+
+	created.Hex = result.RawTx
+	return
+}
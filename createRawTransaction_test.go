@@ -0,0 +1,169 @@
+package gobitcoinclilight
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+func TestCreateRawTransactionOrdered(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	inputs := []TxInput{
+		{TxID: "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944", Vout: 1},
+		{TxID: "9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455", Vout: 1},
+		{TxID: "9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455", Vout: 0},
+	}
+	outputs := []TxOutput{
+		{Address: "tb1qmhqe8pr06v0mefelardj4h6hkq095e5dh72mv3", Amount: 3000},
+		{Address: bitcoindtest.FixtureAddress, Amount: 2000, Change: true},
+		{Data: "48454c4c4f"},
+		{Address: "tb1q3flg4mlnuk2xexu773g8d4lh6nl48rc6w6vhsm", Amount: 3000},
+	}
+
+	created, err := bitcoinRpc.CreateRawTransactionOrdered(inputs, outputs, CreateRawTransactionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Hex != bitcoindtest.FixtureRawTx || created.ChangeVout != 1 || created.Vout("tb1q3flg4mlnuk2xexu773g8d4lh6nl48rc6w6vhsm") != 3 {
+		t.Fatalf("unexpected created %+v", created)
+	}
+	checkOutputParams(t, server, 0, []string{"tb1qmhqe8pr06v0mefelardj4h6hkq095e5dh72mv3", bitcoindtest.FixtureAddress, "data", "tb1q3flg4mlnuk2xexu773g8d4lh6nl48rc6w6vhsm"})
+
+	// BIP69: 0 (data), 2000, then the two 3000 by scriptPubKey: 00148a7e... < 0014ddc1...
+	created, err = bitcoinRpc.CreateRawTransactionOrdered(inputs, outputs, CreateRawTransactionOptions{BIP69: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.ChangeVout != 1 || created.Inputs[0].Vout != 0 || created.Inputs[1].Vout != 1 || created.Inputs[2].TxID != inputs[0].TxID {
+		t.Fatalf("unexpected created %+v", created)
+	}
+	checkOutputParams(t, server, 1, []string{"data", bitcoindtest.FixtureAddress, "tb1q3flg4mlnuk2xexu773g8d4lh6nl48rc6w6vhsm", "tb1qmhqe8pr06v0mefelardj4h6hkq095e5dh72mv3"})
+	if outputs[0].Address != "tb1qmhqe8pr06v0mefelardj4h6hkq095e5dh72mv3" || inputs[0].Vout != 1 {
+		t.Fatalf("the arguments must not be reordered")
+	}
+
+	incorrects := [][]TxOutput{
+		{},
+		{{Address: bitcoindtest.FixtureAddress, Amount: -1}},
+		{{Amount: 1}},
+		{{Data: "00"}, {Data: "01"}},
+		{{Address: bitcoindtest.FixtureAddress, Change: true}, {Address: "tb1q3flg4mlnuk2xexu773g8d4lh6nl48rc6w6vhsm", Change: true}},
+	}
+	for _, incorrect := range incorrects {
+		if _, err = bitcoinRpc.CreateRawTransactionOrdered(inputs, incorrect, CreateRawTransactionOptions{}); err == nil {
+			t.Fatalf("expected an error for %+v", incorrect)
+		}
+	}
+}
+
+func TestSortBIP69(t *testing.T) {
+
+	inputs := []TxInput{
+		{TxID: "B0EA0B9F9BB6326BC4D339A71EA41F7592F0F9DD9DDCB7D6B14EDCB6959D1944", Vout: 0},
+		{TxID: "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90", Vout: 0},
+		{TxID: "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944", Vout: 1},
+		{TxID: "9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455", Vout: 1},
+	}
+	if err := SortBIP69(inputs, nil); err != nil {
+		t.Fatal(err)
+	}
+	if inputs[0].TxID[0] != '9' || inputs[1].TxID[0] != 'a' || inputs[2].TxID[0] != 'B' || inputs[3].Vout != 1 {
+		t.Fatalf("unexpected order %+v", inputs)
+	}
+}
+
+func checkOutputParams(t *testing.T, server *bitcoindtest.Server, call int, expected []string) {
+
+	outputParams := make([]map[string]json.RawMessage, 0)
+	if err := server.RequestsFor("createrawtransaction")[call].Param(1, &outputParams); err != nil {
+		t.Fatal(err)
+	}
+	if len(outputParams) != len(expected) {
+		t.Fatalf("unexpected outputs %+v", outputParams)
+	}
+	for i, key := range expected {
+		if _, ok := outputParams[i][key]; !ok {
+			t.Fatalf("outputs[%d]: expected %s, got %+v", i, key, outputParams[i])
+		}
+	}
+}
+
+func TestCreateRawTransactionOptions(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	sequence := uint32(10)
+	inputs := []TxInput{
+		{TxID: "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944", Vout: 1, Sequence: &sequence},
+		{TxID: "9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455", Vout: 1},
+	}
+	outputs := []TxOutput{{Address: bitcoindtest.FixtureAddress, Amount: 24000}}
+
+	_, err := bitcoinRpc.CreateRawTransactionOrdered(inputs, outputs, CreateRawTransactionOptions{LockTime: 2344981, Replaceable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := server.RequestsFor("createrawtransaction")[0]
+	params := request.Params
+	if len(params) != 4 || string(params[2]) != "2344981" || string(params[3]) != "true" {
+		t.Fatalf("unexpected params %s", params)
+	}
+	if string(params[0]) != `[{"txid":"b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944","vout":1,"sequence":10},{"txid":"9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455","vout":1}]` {
+		t.Fatalf("unexpected inputs %s", params[0])
+	}
+
+	// replaceable is sent even when false, bitcoind defaulting to true since v24
+	for call, options := range []CreateRawTransactionOptions{{}, {LockTime: 2344981}} {
+		if _, err = bitcoinRpc.CreateRawTransactionOrdered(inputs, outputs, options); err != nil {
+			t.Fatal(err)
+		}
+		params = server.RequestsFor("createrawtransaction")[1+call].Params
+		if len(params) != 4 || string(params[2]) != fmt.Sprint(options.LockTime) || string(params[3]) != "false" {
+			t.Fatalf("%+v: unexpected params %s", options, params)
+		}
+	}
+
+	final := SequenceFinal
+	incorrects := [][]TxInput{
+		{{TxID: "b0ea0b9f", Vout: 1}},
+		{{TxID: "z0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944", Vout: 1}},
+		{{TxID: inputs[0].TxID, Vout: -1}},
+		{{TxID: inputs[0].TxID, Vout: 1}, {TxID: inputs[0].TxID, Vout: 1, Sequence: &sequence}},
+		{{TxID: inputs[0].TxID, Vout: 1, Sequence: &final}},
+	}
+	for _, incorrect := range incorrects {
+		if _, err = bitcoinRpc.CreateRawTransactionOrdered(incorrect, outputs, CreateRawTransactionOptions{Replaceable: true}); err == nil {
+			t.Fatalf("expected an error for %+v", incorrect)
+		}
+	}
+
+	incorrectMaps := []map[string]interface{}{
+		{"txid": inputs[0].TxID},
+		{"txid": inputs[0].TxID, "vout": 1.5},
+		{"txid": inputs[0].TxID, "vout": "1"},
+		{"txid": 1, "vout": 1},
+		{"txid": inputs[0].TxID, "vout": 1, "sequence": -1},
+	}
+	for _, incorrect := range incorrectMaps {
+		_, err = bitcoinRpc.CreateRawTransaction([]map[string]interface{}{incorrect}, map[string]Amount{bitcoindtest.FixtureAddress: 24000}, "")
+		if err == nil {
+			t.Fatalf("expected an error for %+v", incorrect)
+		}
+	}
+
+	_, err = bitcoinRpc.CreateRawTransaction([]map[string]interface{}{{"txid": inputs[0].TxID, "vout": float64(1), "sequence": 0}}, map[string]Amount{bitcoindtest.FixtureAddress: 24000}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inputsParam := string(server.RequestsFor("createrawtransaction")[3].Params[0]); inputsParam != `[{"txid":"b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944","vout":1,"sequence":0}]` {
+		t.Fatalf("unexpected inputs %s", inputsParam)
+	}
+}
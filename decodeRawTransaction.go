@@ -0,0 +1,107 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type DecodedRawTransaction struct {
+	TxID     string `json:"txid"`     // (string) The transaction id
+	Hash     string `json:"hash"`     // (string) The transaction hash (differs from txid for witness transactions)
+	Version  int32  `json:"version"`  // (numeric) The version
+	Size     int64  `json:"size"`     // (numeric) The transaction size
+	VSize    int64  `json:"vsize"`    // (numeric) The virtual transaction size (differs from size for witness transactions)
+	Weight   int64  `json:"weight"`   // (numeric) The transaction's weight (between vsize*4-3 and vsize*4)
+	LockTime int64  `json:"locktime"` // (numeric) The lock time
+	Vin      []Vin  `json:"vin"`
+	Vout     []Vout `json:"vout"`
+}
+
+func (bitcoinRpc BitcoinRpc) DecodeRawTransaction(rawTx string) (decodedTx DecodedRawTransaction, err error) {
+	return bitcoinRpc.DecodeRawTransactionCtx(context.Background(), rawTx)
+}
+
+func (bitcoinRpc BitcoinRpc) DecodeRawTransactionCtx(ctx context.Context, rawTx string) (decodedTx DecodedRawTransaction, err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "decoderawtransaction"
+	jsonRpcInfo["params"] = []interface{}{rawTx}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultDecodeRawTx struct {
+		DecodedTx DecodedRawTransaction `json:"result"`
+	}
+	result := resultDecodeRawTx{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	decodedTx = result.DecodedTx
+	return
+}
+
+type DecodedSegwitScript struct {
+	Asm        string `json:"asm"`         // (string) Disassembly of the output script
+	Hex        string `json:"hex"`         // (string) The raw output script bytes, hex-encoded
+	Type       string `json:"type"`        // (string) The type of the output script (e.g. witness_v0_keyhash or witness_v0_scripthash)
+	Address    string `json:"address"`     // (string) The Bitcoin address (only if a well-defined address exists)
+	Desc       string `json:"desc"`        // (string) Inferred descriptor for the script
+	P2SHSegwit string `json:"p2sh-segwit"` // (string) address of the P2SH script wrapping this witness redeem script
+}
+
+type DecodedScript struct {
+	Asm     string               `json:"asm"`     // (string) Script public key
+	Desc    string               `json:"desc"`    // (string) Inferred descriptor for the script
+	Type    string               `json:"type"`    // (string) The output type (e.g. nonstandard, anchor, pubkey, pubkeyhash, scripthash, ...)
+	Address string               `json:"address"` // (string) The Bitcoin address (only if a well-defined address exists)
+	P2SH    string               `json:"p2sh"`    // (string) address of P2SH script wrapping this redeem script (not returned for types that should not be wrapped)
+	Segwit  *DecodedSegwitScript `json:"segwit"`  // (json object) Result of a witness output script wrapping this redeem script (not returned for types that should not be wrapped)
+}
+
+func (bitcoinRpc BitcoinRpc) DecodeScript(scriptHex string) (decodedScript DecodedScript, err error) {
+	return bitcoinRpc.DecodeScriptCtx(context.Background(), scriptHex)
+}
+
+func (bitcoinRpc BitcoinRpc) DecodeScriptCtx(ctx context.Context, scriptHex string) (decodedScript DecodedScript, err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "decodescript"
+	jsonRpcInfo["params"] = []interface{}{scriptHex}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultDecodeScript struct {
+		DecodedScript DecodedScript `json:"result"`
+	}
+	result := resultDecodeScript{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	decodedScript = result.DecodedScript
+	return
+}
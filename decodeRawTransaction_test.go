@@ -0,0 +1,89 @@
+package gobitcoinclilight
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+func TestDecodeRawTransaction(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	decodedTx, err := bitcoinRpc.DecodeRawTransaction(bitcoindtest.FixtureSignedRawTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rawTx := ""; server.RequestsFor("decoderawtransaction")[0].Param(0, &rawTx) != nil || rawTx != bitcoindtest.FixtureSignedRawTx {
+		t.Fatalf("unexpected param %s", rawTx)
+	}
+
+	// what bitcoind decoded must agree with the local decoder
+	tx, err := DecodeTransactionHex(bitcoindtest.FixtureSignedRawTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodedTx.TxID != tx.TxID() || decodedTx.Hash != tx.WTxID() || decodedTx.VSize != int64(tx.VSize()) || decodedTx.Weight != int64(tx.Weight()) || decodedTx.Version != tx.Version {
+		t.Fatalf("unexpected decodedTx %+v", decodedTx)
+	}
+	for i, vin := range decodedTx.Vin {
+		input := tx.Inputs[i]
+		if vin.TxID != input.PrevTxID.String() || vin.Sequence != input.Sequence || vin.ScriptSig.Hex != hex.EncodeToString(input.ScriptSig) || len(vin.TxInWitness) != len(input.Witness) {
+			t.Fatalf("unexpected vin[%d] %+v", i, vin)
+		}
+		for j, item := range vin.TxInWitness {
+			if item != hex.EncodeToString(input.Witness[j]) {
+				t.Fatalf("unexpected vin[%d] witness[%d] %s", i, j, item)
+			}
+		}
+	}
+	for n, vout := range decodedTx.Vout {
+		output := tx.Outputs[n]
+		if vout.N != n || vout.Value != output.Value || vout.ScriptPubKey.Hex != hex.EncodeToString(output.ScriptPubKey) {
+			t.Fatalf("unexpected vout[%d] %+v", n, vout)
+		}
+	}
+	scriptPubKey := decodedTx.Vout[1].ScriptPubKey
+	if scriptPubKey.Type != "witness_v0_keyhash" || scriptPubKey.Address != bitcoindtest.FixtureAddress || scriptPubKey.Desc == "" || decodedTx.Vout[0].ScriptPubKey.Type != "nulldata" {
+		t.Fatalf("unexpected scriptPubKey %+v", scriptPubKey)
+	}
+}
+
+func TestDecodeScript(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	decodedScript, err := bitcoinRpc.DecodeScript("00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodedScript.Type != "witness_v0_keyhash" || decodedScript.Address != bitcoindtest.FixtureAddress || decodedScript.P2SH != "2N9CPBaJE3aH3CohrLQtA2ZLwoprZFXhu36" || decodedScript.Segwit != nil {
+		t.Fatalf("unexpected decodedScript %+v", decodedScript)
+	}
+
+	server.SetResult("decodescript", map[string]interface{}{
+		"asm":  "1 OP_DROP",
+		"desc": "raw(5175)#ww5xwgnh",
+		"type": "nonstandard",
+		"p2sh": "2N5Ccp6WNE4HmCzmv4K5B8LU1GnkmDhNCcz",
+		"segwit": map[string]interface{}{
+			"asm":         "0 e4bd7e8b4ac5a69d1fd6ae8d0a6cf08e4b3d7a90e01f40fcf4f2b2aa2b40bf6e",
+			"hex":         "0020e4bd7e8b4ac5a69d1fd6ae8d0a6cf08e4b3d7a90e01f40fcf4f2b2aa2b40bf6e",
+			"type":        "witness_v0_scripthash",
+			"address":     "tb1qujkhaz62ckhf687k46xs5m8s3e9n6755uq05pl857te252eqhahqy0zdqf",
+			"p2sh-segwit": "2NC3TBfKkHbsvMY6tqQpZsoRhDAjTpjbRLY",
+		},
+	})
+	decodedScript, err = bitcoinRpc.DecodeScript("5175")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodedScript.Segwit == nil || decodedScript.Segwit.Type != "witness_v0_scripthash" || decodedScript.Segwit.P2SHSegwit != "2NC3TBfKkHbsvMY6tqQpZsoRhDAjTpjbRLY" {
+		t.Fatalf("unexpected segwit %+v", decodedScript.Segwit)
+	}
+}
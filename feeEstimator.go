@@ -0,0 +1,163 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxBlockVSize is the vsize of a full block: the maximum weight of 4M / 4.
+const MaxBlockVSize = 1000000
+
+// FeeEstimator gives the fee rate to confirm within confTarget blocks.
+type FeeEstimator interface {
+	EstimateFeeRate(ctx context.Context, confTarget int) (feeRate FeeRate, err error)
+}
+
+// NodeFeeEstimator asks estimatesmartfee of the node.
+type NodeFeeEstimator struct {
+	BitcoinRpc BitcoinRpc
+	Mode       string // see EstimateSmartFee
+}
+
+func (estimator NodeFeeEstimator) EstimateFeeRate(ctx context.Context, confTarget int) (feeRate FeeRate, err error) {
+
+	estimate, err := estimator.BitcoinRpc.EstimateSmartFeeCtx(ctx, confTarget, estimator.Mode)
+	if err != nil {
+		err = fmt.Errorf("@estimator.BitcoinRpc.EstimateSmartFeeCtx(ctx, %d, %q): %w", confTarget, estimator.Mode, err)
+		return
+	}
+	feeRate = estimate.FeeRate
+	return
+}
+
+// MempoolFeeEstimator projects the next confTarget blocks from the verbose
+// getrawmempool, filling them by fee rate, and returns the fee rate at
+// Percentile of their vsize: 0 is the lowest fee rate which still gets in,
+// 0.5 the median. When the mempool doesn't fill them, it returns the
+// mempoolminfee of getmempoolinfo.
+type MempoolFeeEstimator struct {
+	BitcoinRpc BitcoinRpc
+	Percentile float64
+}
+
+func (estimator MempoolFeeEstimator) EstimateFeeRate(ctx context.Context, confTarget int) (feeRate FeeRate, err error) {
+
+	if confTarget < 1 {
+		err = fmt.Errorf("incorrect confTarget[%d]", confTarget)
+		return
+	}
+	if estimator.Percentile < 0 || estimator.Percentile > 1 {
+		err = fmt.Errorf("incorrect Percentile[%v]", estimator.Percentile)
+		return
+	}
+
+	entries, err := estimator.BitcoinRpc.GetRawMempoolVerboseCtx(ctx)
+	if err != nil {
+		err = fmt.Errorf("@estimator.BitcoinRpc.GetRawMempoolVerboseCtx(ctx): %w", err)
+		return
+	}
+
+	sorted := make([]MempoolEntry, 0, len(entries))
+	for _, entry := range entries {
+		sorted = append(sorted, entry)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].FeeRate() > sorted[j].FeeRate() })
+
+	capacity := int64(confTarget) * MaxBlockVSize
+	projected, projectedVSize := 0, int64(0)
+	for ; projected < len(sorted) && projectedVSize+sorted[projected].VSize <= capacity; projected++ {
+		projectedVSize += sorted[projected].VSize
+	}
+	if projected == 0 && len(sorted) > 0 {
+		feeRate = sorted[0].FeeRate()
+		return
+	}
+	if projected == len(sorted) {
+		info, errInfo := estimator.BitcoinRpc.GetMempoolInfoCtx(ctx)
+		if errInfo != nil {
+			err = fmt.Errorf("@estimator.BitcoinRpc.GetMempoolInfoCtx(ctx): %w", errInfo)
+			return
+		}
+		feeRate = FeeRate(info.MempoolMinFee)
+		return
+	}
+
+	// walk up from the lowest fee rate of the projected blocks
+	position, cumulative := int64(estimator.Percentile*float64(projectedVSize)), int64(0)
+	for i := projected - 1; i >= 0; i-- {
+		cumulative += sorted[i].VSize
+		feeRate = sorted[i].FeeRate()
+		if cumulative >= position {
+			break
+		}
+	}
+	return
+}
+
+// StaticFeeEstimator always returns FeeRate.
+type StaticFeeEstimator struct {
+	FeeRate FeeRate
+}
+
+func (estimator StaticFeeEstimator) EstimateFeeRate(ctx context.Context, confTarget int) (feeRate FeeRate, err error) {
+	return estimator.FeeRate, nil
+}
+
+// BoundedFeeEstimator clamps the estimate of Estimator between Floor and
+// Ceiling (zero for none), e.g. to the minimum relay fee and a sanity limit.
+type BoundedFeeEstimator struct {
+	Estimator FeeEstimator
+	Floor     FeeRate
+	Ceiling   FeeRate
+}
+
+func (estimator BoundedFeeEstimator) EstimateFeeRate(ctx context.Context, confTarget int) (feeRate FeeRate, err error) {
+
+	if estimator.Ceiling > 0 && estimator.Ceiling < estimator.Floor {
+		err = fmt.Errorf("ceiling %s below floor %s", estimator.Ceiling, estimator.Floor)
+		return
+	}
+	feeRate, err = estimator.Estimator.EstimateFeeRate(ctx, confTarget)
+	if err != nil {
+		return
+	}
+	if feeRate < estimator.Floor {
+		feeRate = estimator.Floor
+	}
+	if estimator.Ceiling > 0 && feeRate > estimator.Ceiling {
+		feeRate = estimator.Ceiling
+	}
+	return
+}
+
+// FallbackFeeEstimator returns the first estimate which succeeds, e.g. of a
+// NodeFeeEstimator, then a MempoolFeeEstimator, then a StaticFeeEstimator.
+type FallbackFeeEstimator []FeeEstimator
+
+func (estimators FallbackFeeEstimator) EstimateFeeRate(ctx context.Context, confTarget int) (feeRate FeeRate, err error) {
+
+	if len(estimators) == 0 {
+		err = fmt.Errorf("len(estimators) == 0")
+		return
+	}
+
+	failures := []string{}
+	for index, estimator := range estimators {
+		feeRate, err = estimator.EstimateFeeRate(ctx, confTarget)
+		if err == nil {
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if index < len(estimators)-1 {
+			failures = append(failures, fmt.Sprintf("estimators[%d]: %v", index, err))
+		}
+	}
+	if len(failures) > 0 {
+		err = fmt.Errorf("%w (after %s)", err, strings.Join(failures, "; "))
+	}
+	return
+}
@@ -0,0 +1,100 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+func mempoolEntryFixture(vsize int64, fee float64) map[string]interface{} {
+	return map[string]interface{}{
+		"vsize":        vsize,
+		"ancestorsize": vsize,
+		"fees":         map[string]interface{}{"base": fee, "modified": fee, "ancestor": fee, "descendant": fee},
+	}
+}
+
+func TestMempoolEntryFeeRate(t *testing.T) {
+
+	entry := MempoolEntry{VSize: 100, AncestorSize: 300, Fees: MempoolEntryFees{Modified: 2000, Ancestor: 2300}}
+	// 20 sat/vB, but its parent pays 1.5 sat/vB for 200 vB
+	if feeRate := entry.FeeRate(); feeRate != 7666 {
+		t.Fatalf("unexpected feeRate %s", feeRate)
+	}
+	entry.Fees.Ancestor = 9000
+	if feeRate := entry.FeeRate(); feeRate != 20000 {
+		t.Fatalf("unexpected feeRate %s", feeRate)
+	}
+}
+
+func TestFeeEstimators(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	server.SetResult("getrawmempool", map[string]interface{}{
+		"a": mempoolEntryFixture(400000, 0.04), // 10 sat/vB
+		"b": mempoolEntryFixture(400000, 0.08), // 20 sat/vB
+		"c": mempoolEntryFixture(400000, 0.12), // 30 sat/vB
+	})
+	bitcoinRpc := newTestBitcoinRpc(server)
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		estimator  FeeEstimator
+		confTarget int
+		expected   FeeRate
+	}{
+		{NodeFeeEstimator{BitcoinRpc: bitcoinRpc}, 2, 12345},
+		// b and c fill the next block
+		{MempoolFeeEstimator{BitcoinRpc: bitcoinRpc}, 1, 20000},
+		{MempoolFeeEstimator{BitcoinRpc: bitcoinRpc, Percentile: 0.5}, 1, 20000},
+		{MempoolFeeEstimator{BitcoinRpc: bitcoinRpc, Percentile: 0.75}, 1, 30000},
+		{MempoolFeeEstimator{BitcoinRpc: bitcoinRpc, Percentile: 1}, 1, 30000},
+		// everything fits in 2 blocks: mempoolminfee
+		{MempoolFeeEstimator{BitcoinRpc: bitcoinRpc}, 2, 1000},
+		{StaticFeeEstimator{FeeRate: 5000}, 6, 5000},
+		{BoundedFeeEstimator{Estimator: StaticFeeEstimator{FeeRate: 500}, Floor: 1000, Ceiling: 100000}, 6, 1000},
+		{BoundedFeeEstimator{Estimator: StaticFeeEstimator{FeeRate: 500000}, Floor: 1000, Ceiling: 100000}, 6, 100000},
+		{BoundedFeeEstimator{Estimator: StaticFeeEstimator{FeeRate: 500000}, Floor: 1000}, 6, 500000},
+	} {
+		feeRate, err := test.estimator.EstimateFeeRate(ctx, test.confTarget)
+		if err != nil || feeRate != test.expected {
+			t.Fatalf("%+v: unexpected feeRate %d instead of %d: %v", test.estimator, feeRate, test.expected, err)
+		}
+	}
+
+	if _, err := (MempoolFeeEstimator{BitcoinRpc: bitcoinRpc, Percentile: 2}).EstimateFeeRate(ctx, 1); err == nil {
+		t.Fatalf("expected an error for a percentile of 2")
+	}
+	if _, err := (BoundedFeeEstimator{Estimator: StaticFeeEstimator{}, Floor: 2000, Ceiling: 1000}).EstimateFeeRate(ctx, 1); err == nil {
+		t.Fatalf("expected an error for a ceiling below the floor")
+	}
+
+	// without a node estimate, the mempool one
+	server.SetResult("estimatesmartfee", map[string]interface{}{"errors": []string{"Insufficient data or no feerate found"}, "blocks": 0})
+	fallback := FallbackFeeEstimator{NodeFeeEstimator{BitcoinRpc: bitcoinRpc}, MempoolFeeEstimator{BitcoinRpc: bitcoinRpc}, StaticFeeEstimator{FeeRate: 5000}}
+	feeRate, err := fallback.EstimateFeeRate(ctx, 1)
+	if err != nil || feeRate != 20000 {
+		t.Fatalf("unexpected feeRate %d: %v", feeRate, err)
+	}
+
+	server.SetError("getrawmempool", -32603, "mempool unavailable")
+	feeRate, err = fallback.EstimateFeeRate(ctx, 1)
+	if err != nil || feeRate != 5000 {
+		t.Fatalf("unexpected feeRate %d: %v", feeRate, err)
+	}
+
+	_, err = fallback[:2].EstimateFeeRate(ctx, 1)
+	if err == nil || !strings.Contains(err.Error(), "mempool unavailable") || !strings.Contains(err.Error(), "estimators[0]") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err = (FallbackFeeEstimator{}).EstimateFeeRate(ctx, 1); err == nil {
+		t.Fatalf("expected an error without estimators")
+	}
+	if _, err = (FallbackFeeEstimator{NodeFeeEstimator{BitcoinRpc: bitcoinRpc}}).EstimateFeeRate(ctx, 1); !errors.Is(err, ErrNoFeeEstimate) {
+		t.Fatalf("expected ErrNoFeeEstimate, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// FeeRate is a fee rate in satoshis per 1000 vbytes, the precision of
+// bitcoind. In JSON it is the BTC/kvB value of the fee rates of the RPCs.
+type FeeRate int64
+
+// NewFeeRateFromSatPerVByte rounds satPerVByte to the nearest sat/kvB.
+func NewFeeRateFromSatPerVByte(satPerVByte float64) FeeRate {
+	return FeeRate(math.Round(satPerVByte * 1000))
+}
+
+// FeeRateOf is the fee rate of paying fee for vsize vbytes, rounded down.
+func FeeRateOf(fee Amount, vsize int64) FeeRate {
+	if vsize <= 0 {
+		return 0
+	}
+	return FeeRate(int64(fee) * 1000 / vsize)
+}
+
+func (feeRate FeeRate) SatPerVByte() float64 {
+	return float64(feeRate) / 1000
+}
+
+// BTCPerKvB is the fee rate as the amount paid for 1000 vbytes.
+func (feeRate FeeRate) BTCPerKvB() Amount {
+	return Amount(feeRate)
+}
+
+// Fee is the fee for vsize vbytes, rounded up like bitcoind's CFeeRate::GetFee.
+func (feeRate FeeRate) Fee(vsize int64) Amount {
+	return Amount((int64(feeRate)*vsize + 999) / 1000)
+}
+
+func (feeRate FeeRate) String() string {
+	return fmt.Sprintf("%.3f sat/vB", feeRate.SatPerVByte())
+}
+
+func (feeRate FeeRate) MarshalJSON() ([]byte, error) {
+	return Amount(feeRate).MarshalJSON()
+}
+
+func (feeRate *FeeRate) UnmarshalJSON(data []byte) (err error) {
+	amount := Amount(0)
+	err = amount.UnmarshalJSON(data)
+	*feeRate = FeeRate(amount)
+	return
+}
+
+// estimate modes of estimatesmartfee
+const (
+	EstimateModeUnset        = "unset"
+	EstimateModeEconomical   = "economical"
+	EstimateModeConservative = "conservative"
+)
+
+var ErrNoFeeEstimate = errors.New("no fee estimate")
+
+type SmartFeeEstimate struct {
+	FeeRate FeeRate  `json:"feerate"` // (numeric, optional) estimate fee rate in BTC/kvB (only present if no errors were encountered)
+	Errors  []string `json:"errors"`  // (json array, optional) Errors encountered during processing (if there are any)
+	Blocks  int      `json:"blocks"`  // (numeric) block number where estimate was found
+}
+
+func (bitcoinRpc BitcoinRpc) EstimateSmartFee(confTarget int, mode string) (estimate SmartFeeEstimate, err error) {
+	return bitcoinRpc.EstimateSmartFeeCtx(context.Background(), confTarget, mode)
+}
+
+// EstimateSmartFeeCtx estimates the fee rate to confirm within confTarget
+// blocks (1 to 1008). An empty mode keeps the default of bitcoind. Without an
+// estimate, e.g. right after startup or on regtest, the error wraps
+// ErrNoFeeEstimate.
+func (bitcoinRpc BitcoinRpc) EstimateSmartFeeCtx(ctx context.Context, confTarget int, mode string) (estimate SmartFeeEstimate, err error) {
+
+	if confTarget < 1 || confTarget > 1008 {
+		err = fmt.Errorf("incorrect confTarget[%d]", confTarget)
+		return
+	}
+	params := []interface{}{confTarget}
+	switch mode {
+	case "":
+	case EstimateModeUnset, EstimateModeEconomical, EstimateModeConservative:
+		params = append(params, mode)
+	default:
+		err = fmt.Errorf("incorrect mode[%s]", mode)
+		return
+	}
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "estimatesmartfee"
+	jsonRpcInfo["params"] = params
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultEstimateSmartFee struct {
+		Estimate SmartFeeEstimate `json:"result"`
+	}
+	result := resultEstimateSmartFee{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	estimate = result.Estimate
+	if estimate.FeeRate <= 0 {
+		err = fmt.Errorf("%w for %d blocks: %s", ErrNoFeeEstimate, confTarget, strings.Join(estimate.Errors, ", "))
+		return
+	}
+	return
+}
@@ -0,0 +1,70 @@
+package gobitcoinclilight
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+func TestFeeRate(t *testing.T) {
+
+	feeRate := NewFeeRateFromSatPerVByte(4.5045)
+	if feeRate != 4505 || feeRate.SatPerVByte() != 4.505 || feeRate.String() != "4.505 sat/vB" || feeRate.BTCPerKvB() != 4505 {
+		t.Fatalf("unexpected feeRate %d", feeRate)
+	}
+	// rounded up: 4.505 * 222 = 1000.11
+	if fee := feeRate.Fee(222); fee != 1001 {
+		t.Fatalf("unexpected fee %d", fee)
+	}
+	if fee := FeeRate(1000).Fee(141); fee != 141 {
+		t.Fatalf("unexpected fee %d", fee)
+	}
+	if feeRate = FeeRateOf(1000, 222); feeRate != 4504 || FeeRateOf(1000, 0) != 0 {
+		t.Fatalf("unexpected feeRate %d", feeRate)
+	}
+
+	data, err := json.Marshal(FeeRate(12345))
+	if err != nil || string(data) != "0.00012345" {
+		t.Fatalf("unexpected json %s: %v", data, err)
+	}
+	if err = json.Unmarshal([]byte("0.00001"), &feeRate); err != nil || feeRate != 1000 {
+		t.Fatalf("unexpected feeRate %d: %v", feeRate, err)
+	}
+}
+
+func TestEstimateSmartFee(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	estimate, err := bitcoinRpc.EstimateSmartFee(2, EstimateModeEconomical)
+	if err != nil || estimate.FeeRate != 12345 || estimate.FeeRate.SatPerVByte() != 12.345 || estimate.Blocks != 2 {
+		t.Fatalf("unexpected estimate %+v: %v", estimate, err)
+	}
+	if params := server.RequestsFor("estimatesmartfee")[0].Params; len(params) != 2 || string(params[1]) != `"economical"` {
+		t.Fatalf("unexpected params %s", params)
+	}
+	if _, err = bitcoinRpc.EstimateSmartFee(6, ""); err != nil {
+		t.Fatal(err)
+	}
+	if params := server.RequestsFor("estimatesmartfee")[1].Params; len(params) != 1 {
+		t.Fatalf("unexpected params %s", params)
+	}
+
+	for _, confTarget := range []int{0, 1009} {
+		if _, err = bitcoinRpc.EstimateSmartFee(confTarget, ""); err == nil {
+			t.Fatalf("expected an error for confTarget %d", confTarget)
+		}
+	}
+	if _, err = bitcoinRpc.EstimateSmartFee(2, "fast"); err == nil {
+		t.Fatalf("expected an error for mode fast")
+	}
+
+	server.SetResult("estimatesmartfee", map[string]interface{}{"errors": []string{"Insufficient data or no feerate found"}, "blocks": 0})
+	if _, err = bitcoinRpc.EstimateSmartFee(2, ""); !errors.Is(err, ErrNoFeeEstimate) {
+		t.Fatalf("expected ErrNoFeeEstimate, got %v", err)
+	}
+}
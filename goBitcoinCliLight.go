@@ -2,11 +2,17 @@ package gobitcoinclilight
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"math"
+	"net"
 	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type BitcoinRpc struct {
@@ -15,42 +21,173 @@ type BitcoinRpc struct {
 	RpcConnect string
 	RpcPort    string
 	RpcPath    string
+	RpcTimeout time.Duration // per-request timeout, 0 means no timeout besides the ctx deadline
+	RpcScheme  string        // "http" (default) or "https"
+	RpcAuth    RpcAuth       // overrides RpcUser/RpcPW when set, e.g. NewCookieAuth(".../.cookie")
+
+	RpcRetryPolicy *RetryPolicy // for read-only calls, nil means DefaultRetryPolicy
+
+	httpClient *http.Client // set by NewClient, defaultHttpClient otherwise
 }
 
+var jsonRpcID uint64
+
+// defaultJsonRpcInfo gives every request its own id so batched responses can be matched back to their calls.
 func defaultJsonRpcInfo() (info map[string]interface{}) {
 	info = make(map[string]interface{})
 	info["jsonrpc"] = "1.0"
-	info["id"] = "GoBitcoinCliLight"
+	info["id"] = fmt.Sprintf("GoBitcoinCliLight-%d", atomic.AddUint64(&jsonRpcID, 1))
 	return
 }
 
-func (bitcoinRpc BitcoinRpc) request(jsonRpcBytes []byte) (body []byte, err error) {
+// request posts jsonRpcBytes to bitcoind. The HTTP call is bound to ctx, so a
+// cancelled ctx or an expired deadline aborts it and the returned error wraps
+// ctx.Err() (e.g. context.DeadlineExceeded) rather than a transport error.
+func (bitcoinRpc BitcoinRpc) request(ctx context.Context, jsonRpcBytes []byte) (body []byte, err error) {
+
+	if bitcoinRpc.RpcTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, bitcoinRpc.RpcTimeout)
+		defer cancel()
+	}
+
+	if bitcoinRpc.RpcAuth == nil {
+		return bitcoinRpc.post(ctx, jsonRpcBytes, bitcoinRpc.RpcUser, bitcoinRpc.RpcPW)
+	}
+
+	rpcUser, rpcPW, err := bitcoinRpc.RpcAuth.Credentials()
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.RpcAuth.Credentials(): %w", err)
+		return
+	}
+	body, err = bitcoinRpc.post(ctx, jsonRpcBytes, rpcUser, rpcPW)
+	if !errors.Is(err, ErrUnauthorized) {
+		return
+	}
 
-	request, err := http.NewRequest("POST", fmt.Sprintf("http://%s:%s/%s", bitcoinRpc.RpcConnect, bitcoinRpc.RpcPort, bitcoinRpc.RpcPath), bytes.NewBuffer(jsonRpcBytes))
+	// e.g. the cookie was rotated by a node restart
+	refreshed, errRefresh := bitcoinRpc.RpcAuth.Refresh()
+	if errRefresh != nil || !refreshed {
+		return
+	}
+	rpcUser, rpcPW, err = bitcoinRpc.RpcAuth.Credentials()
 	if err != nil {
-		err = fmt.Errorf("@http.NewRequest('POST', ...): %v", err)
+		err = fmt.Errorf("@bitcoinRpc.RpcAuth.Credentials(): %w", err)
+		return
+	}
+	return bitcoinRpc.post(ctx, jsonRpcBytes, rpcUser, rpcPW)
+}
+
+func (bitcoinRpc BitcoinRpc) post(ctx context.Context, jsonRpcBytes []byte, rpcUser string, rpcPW string) (body []byte, err error) {
+
+	rpcScheme := bitcoinRpc.RpcScheme
+	if rpcScheme == "" {
+		rpcScheme = "http"
+	}
+	request, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s://%s/%s", rpcScheme, net.JoinHostPort(bitcoinRpc.RpcConnect, bitcoinRpc.RpcPort), bitcoinRpc.RpcPath), bytes.NewBuffer(jsonRpcBytes))
+	if err != nil {
+		err = fmt.Errorf("@http.NewRequestWithContext(ctx, 'POST', ...): %w", err)
 		return
 	}
 	request.Header.Set("content-type", "text/plain;")
-	request.SetBasicAuth(bitcoinRpc.RpcUser, bitcoinRpc.RpcPW)
+	request.SetBasicAuth(rpcUser, rpcPW)
 
-	client := &http.Client{}
+	client := bitcoinRpc.httpClient
+	if client == nil {
+		client = defaultHttpClient
+	}
 	resp, err := client.Do(request)
 	if err != nil {
-		err = fmt.Errorf("@client.Do(request): %v", err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("@client.Do(request): %w", ctxErr)
+			return
+		}
+		err = fmt.Errorf("@client.Do(request): %w", err)
 		return
 	}
+	defer resp.Body.Close()
 
 	body, err = io.ReadAll(resp.Body)
 	if err != nil {
-		err = fmt.Errorf("@io.ReadAll(resp.Body): %v", err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = fmt.Errorf("@io.ReadAll(resp.Body): %w", ctxErr)
+			return
+		}
+		err = fmt.Errorf("@io.ReadAll(resp.Body): %w", err)
+		return
+	}
+
+	// bitcoind sends 401/403 with an empty body
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		err = &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
+		return
+	}
+
+	// RPC failures come back as {"result":null,"error":{"code":...,"message":...}},
+	// usually with status 500 (or 404 for an unknown method)
+	type jsonRpcError struct {
+		Error *RPCError `json:"error"`
+	}
+	bodyError := jsonRpcError{}
+	if errUnmarshal := json.Unmarshal(body, &bodyError); errUnmarshal == nil && bodyError.Error != nil {
+		err = bodyError.Error
+		return
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err = &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
 		return
 	}
 	return
 }
 
+type Unspent struct {
+	TxID          string   `json:"txid"`          // (string) the transaction id
+	Vout          int      `json:"vout"`          // (numeric) the vout value
+	Address       string   `json:"address"`       // (string) the bitcoin address
+	Label         string   `json:"label"`         // (string) The associated label, or "" for the default label
+	ScriptPubKey  string   `json:"scriptPubKey"`  // (string) the script key
+	Amount        Amount   `json:"amount"`        // (numeric) the transaction output amount in BTC
+	Confirmations int      `json:"confirmations"` // (numeric) The number of confirmations
+	RedeemScript  string   `json:"redeemScript"`  // (string) The redeemScript if scriptPubKey is P2SH
+	WitnessScript string   `json:"witnessScript"` // (string) witnessScript if the scriptPubKey is P2WSH or P2SH-P2WSH
+	Spendable     bool     `json:"spendable"`     // (boolean) Whether we have the private keys to spend this output
+	Solvable      bool     `json:"solvable"`      // (boolean) Whether we know how to spend this output, ignoring the lack of keys
+	Reused        bool     `json:"reused"`        // (boolean) (only present if avoid_reuse is set) Whether this output is reused/dirty (sent to an address that was previously spent from)
+	Desc          string   `json:"desc"`          // (string) (only when solvable) A descriptor for spending this output
+	ParentDescs   []string `json:"parent_descs"`  //
+	Safe          bool     `json:"safe"`          // (boolean) Whether this output is considered safe to spend. Unconfirmed transactions
+}
+
+// Deprecated: use ListUnspentOfAddressCtx, which returns []Unspent.
 func (bitcoinRpc BitcoinRpc) ListUnspentOfAddress(minconf int, maxconf int, addresses []string) (result []map[string]interface{}, err error) {
 
+	unspents, err := bitcoinRpc.ListUnspentOfAddressCtx(context.Background(), minconf, maxconf, addresses)
+	if err != nil {
+		return
+	}
+
+	result = make([]map[string]interface{}, 0)
+	for _, unspent := range unspents {
+		tmpMap := make(map[string]interface{})
+		inrec, errInner := json.Marshal(unspent)
+		if errInner != nil {
+			err = fmt.Errorf("@json.Marshal(unspent): %v", errInner)
+			return
+		}
+		err = json.Unmarshal(inrec, &tmpMap)
+		if err != nil {
+			err = fmt.Errorf("@json.Unmarshal(inrec, &tmpMap): %v", err)
+			return
+		}
+		result = append(result, tmpMap)
+	}
+
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) ListUnspentOfAddressCtx(ctx context.Context, minconf int, maxconf int, addresses []string) (unspents []Unspent, err error) {
+
 	if minconf <= 1 || minconf >= 9999999 {
 		minconf = 1 // Default
 	}
@@ -58,7 +195,6 @@ func (bitcoinRpc BitcoinRpc) ListUnspentOfAddress(minconf int, maxconf int, addr
 		maxconf = 9999999 // Default
 	}
 
-	result = make([]map[string]interface{}, 0)
 	jsonRpcInfo := defaultJsonRpcInfo()
 	jsonRpcInfo["method"] = "listunspent"
 	jsonRpcInfo["params"] = []interface{}{minconf, maxconf, addresses}
@@ -68,31 +204,14 @@ func (bitcoinRpc BitcoinRpc) ListUnspentOfAddress(minconf int, maxconf int, addr
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
 		return
 	}
 
-	type listUnspentInfo struct {
-		TxID          string   `json:"txid"`          // (string) the transaction id
-		Vout          int      `json:"vout"`          // (numeric) the vout value
-		Address       string   `json:"address"`       // (string) the bitcoin address
-		Label         string   `json:"label"`         // (string) The associated label, or "" for the default label
-		ScriptPutKey  string   `json:"scriptPubKey"`  // (string) the script key
-		Amount        float64  `json:"amount"`        // (numeric) the transaction output amount in BTC
-		Confirmations int      `json:"confirmations"` // (numeric) The number of confirmations
-		RedeemScript  string   `json:"redeemScript"`  // (string) The redeemScript if scriptPubKey is P2SH
-		WitnessScript string   `json:"witnessScript"` // (string) witnessScript if the scriptPubKey is P2WSH or P2SH-P2WSH
-		Spendable     bool     `json:"spendable"`     // (boolean) Whether we have the private keys to spend this output
-		Solvable      bool     `json:"solvable"`      // (boolean) Whether we know how to spend this output, ignoring the lack of keys
-		Reused        bool     `json:"reused"`        // (boolean) (only present if avoid_reuse is set) Whether this output is reused/dirty (sent to an address that was previously spent from)
-		Desc          string   `json:"desc"`          // (string) (only when solvable) A descriptor for spending this output
-		ParentDescs   []string `json:"parent_descs"`  //
-		Safe          bool     `json:"safe"`          // (boolean) Whether this output is considered safe to spend. Unconfirmed transactions
-	}
 	type resultListUnspent struct {
-		ListUnspents []listUnspentInfo `json:"result"`
+		ListUnspents []Unspent `json:"result"`
 	}
 	bodyResult := resultListUnspent{}
 	err = json.Unmarshal(body, &bodyResult)
@@ -101,87 +220,77 @@ func (bitcoinRpc BitcoinRpc) ListUnspentOfAddress(minconf int, maxconf int, addr
 		return
 	}
 
-	for _, listUnspent := range bodyResult.ListUnspents {
-		tmpMap := make(map[string]interface{})
-		inrec, errInner := json.Marshal(listUnspent)
-		if errInner != nil {
-			err = fmt.Errorf("@json.Marshal(listUnspent): %v", err)
-			return
-		}
-		err = json.Unmarshal(inrec, &tmpMap)
-		if err != nil {
-			err = fmt.Errorf("@json.Unmarshal(inrec, &tmpMap): %v", err)
-			return
-		}
-		result = append(result, tmpMap)
+	unspents = bodyResult.ListUnspents
+	if unspents == nil {
+		unspents = make([]Unspent, 0)
 	}
 
 	return
 }
 
-func (bitcoinRpc BitcoinRpc) CreateRawTransaction(inTxUnspents []map[string]interface{}, outAddresses map[string]float64, outDataHex string) (rawTx string, err error) {
+func (bitcoinRpc BitcoinRpc) CreateRawTransaction(inTxUnspents []map[string]interface{}, outAddresses map[string]Amount, outDataHex string) (rawTx string, err error) {
+	return bitcoinRpc.CreateRawTransactionCtx(context.Background(), inTxUnspents, outAddresses, outDataHex)
+}
 
-	tCreateTxOuts := make([]map[string]interface{}, 0)
+// CreateRawTransactionCtx orders the outputs by address, then the data output;
+// use CreateRawTransactionOrderedCtx to choose the order, locktime and sequences.
+// Each inTxUnspent needs a "txid" and a "vout", and may have a "sequence".
+func (bitcoinRpc BitcoinRpc) CreateRawTransactionCtx(ctx context.Context, inTxUnspents []map[string]interface{}, outAddresses map[string]Amount, outDataHex string) (rawTx string, err error) {
+
+	inputs := make([]TxInput, 0, len(inTxUnspents))
+	for i, inTxUnspent := range inTxUnspents {
+		txInput, errInput := txInputFromMap(inTxUnspent)
+		if errInput != nil {
+			err = fmt.Errorf("inTxUnspents[%d]: %v", i, errInput)
+			return
+		}
+		inputs = append(inputs, txInput)
+	}
+
+	outputs := make([]TxOutput, 0, len(outAddresses)+1)
 
 	// outParamsAddressAmount
-	for outAddress, outAmount := range outAddresses {
-		tParamsAddress := make(map[string]interface{})
-		outAmount = math.Round((outAmount)*100000000) / 100000000
-		if outAmount < 0.00000000 {
+	tOutAddresses := make([]string, 0, len(outAddresses))
+	for outAddress := range outAddresses {
+		tOutAddresses = append(tOutAddresses, outAddress)
+	}
+	sort.Strings(tOutAddresses)
+	for _, outAddress := range tOutAddresses {
+		outAmount := outAddresses[outAddress]
+		if outAmount < 0 {
 			// Only Filtering when minus-amount
 			// Zero-amount is needed sometimes
 			// Zero-amount will be controlled on service
 			continue
 		}
-		tParamsAddress[outAddress] = outAmount
-		tCreateTxOuts = append(tCreateTxOuts, tParamsAddress)
+		outputs = append(outputs, TxOutput{Address: outAddress, Amount: outAmount})
 	}
 
 	// outParamsData
 	if outDataHex != "" {
-		tCreateTxOuts = append(tCreateTxOuts, map[string]interface{}{"data": outDataHex})
+		outputs = append(outputs, TxOutput{Data: outDataHex})
 	}
 
-	if len(tCreateTxOuts) == 0 {
-		err = fmt.Errorf("len(tCreateTxOuts) == 0 : incorrect outAddresses and outDataHex")
-		return
-	}
-
-	jsonRpcInfo := defaultJsonRpcInfo()
-	jsonRpcInfo["method"] = "createrawtransaction"
-	jsonRpcInfo["params"] = []interface{}{inTxUnspents, tCreateTxOuts}
-	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
-	if err != nil {
-		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+	if len(outputs) == 0 {
+		err = fmt.Errorf("len(outputs) == 0 : incorrect outAddresses and outDataHex")
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	created, err := bitcoinRpc.CreateRawTransactionOrderedCtx(ctx, inputs, outputs, CreateRawTransactionOptions{})
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
-		return
-	}
-
-	type resultCreateRaxTx struct {
-		RawTx string `json:"result"`
-	}
-	result := resultCreateRaxTx{}
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
-		return
-	}
-	rawTx = result.RawTx
-
-	if rawTx == "" {
-		err = fmt.Errorf("rawTx == '': rawTx of result is empty")
+		err = fmt.Errorf("@bitcoinRpc.CreateRawTransactionOrderedCtx(ctx, inputs, outputs, ...): %w", err)
 		return
 	}
 
+	rawTx = created.Hex
 	return
 }
 
 func (bitcoinRpc BitcoinRpc) DumpPrivateKey(address string) (privKey string, err error) {
+	return bitcoinRpc.DumpPrivateKeyCtx(context.Background(), address)
+}
+
+func (bitcoinRpc BitcoinRpc) DumpPrivateKeyCtx(ctx context.Context, address string) (privKey string, err error) {
 
 	jsonRpcInfo := defaultJsonRpcInfo()
 	jsonRpcInfo["method"] = "dumpprivkey"
@@ -192,9 +301,9 @@ func (bitcoinRpc BitcoinRpc) DumpPrivateKey(address string) (privKey string, err
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
 		return
 	}
 
@@ -213,47 +322,136 @@ func (bitcoinRpc BitcoinRpc) DumpPrivateKey(address string) (privKey string, err
 	return
 }
 
+// sighash types of signrawtransactionwithkey; SigHashDefault is for taproot inputs
+const (
+	SigHashDefault            = "DEFAULT"
+	SigHashAll                = "ALL"
+	SigHashNone               = "NONE"
+	SigHashSingle             = "SINGLE"
+	SigHashAllAnyoneCanPay    = "ALL|ANYONECANPAY"
+	SigHashNoneAnyoneCanPay   = "NONE|ANYONECANPAY"
+	SigHashSingleAnyoneCanPay = "SINGLE|ANYONECANPAY"
+)
+
+var ErrIncompleteSignature = errors.New("transaction not completely signed")
+
+// PrevTx is an output spent by the transaction to sign, for outputs the node
+// doesn't know, e.g. of transactions not broadcast yet.
+type PrevTx struct {
+	TxID          string `json:"txid"`                    // (string, required) The transaction id
+	Vout          int    `json:"vout"`                    // (numeric, required) The output number
+	ScriptPubKey  string `json:"scriptPubKey"`            // (string, required) script key
+	RedeemScript  string `json:"redeemScript,omitempty"`  // (string, optional) (required for P2SH) redeem script
+	WitnessScript string `json:"witnessScript,omitempty"` // (string, optional) (required for P2WSH or P2SH-P2WSH) witness script
+	Amount        Amount `json:"amount,omitempty"`        // (numeric or string, optional) (required for Segwit inputs) the amount spent
+}
+
+type SignRawTransactionOptions struct {
+	PrevTxs         []PrevTx
+	SigHashType     string // one of the SigHash consts, empty for the default of bitcoind
+	AllowIncomplete bool   // return an incomplete transaction without error
+}
+
+type SigningError struct {
+	TxID      string   `json:"txid"`      // (string) The hash of the referenced, previous transaction
+	Vout      int      `json:"vout"`      // (numeric) The index of the output to spent and used as input
+	Witness   []string `json:"witness"`   // (json array) The witness of the input
+	ScriptSig string   `json:"scriptSig"` // (string) The hex-encoded signature script
+	Sequence  uint32   `json:"sequence"`  // (numeric) Script sequence number
+	Error     string   `json:"error"`     // (string) Verification or signing error related to the input
+}
+
+type SignedRawTransaction struct {
+	Hex      string         `json:"hex"`      // (string) The hex-encoded raw transaction with signature(s)
+	Complete bool           `json:"complete"` // (boolean) If the transaction has a complete set of signatures
+	Errors   []SigningError `json:"errors"`   // (json array, optional) Script verification errors (if there are any)
+}
+
 func (bitcoinRpc BitcoinRpc) SignRawTransactionWithKey(rawTx string, privKey string) (signedRawTx string, err error) {
+	return bitcoinRpc.SignRawTransactionWithKeyCtx(context.Background(), rawTx, privKey)
+}
+
+// SignRawTransactionWithKeyCtx returns an error wrapping ErrIncompleteSignature
+// when privKey doesn't sign all the inputs.
+func (bitcoinRpc BitcoinRpc) SignRawTransactionWithKeyCtx(ctx context.Context, rawTx string, privKey string) (signedRawTx string, err error) {
+	signed, err := bitcoinRpc.SignRawTransactionWithKeysCtx(ctx, rawTx, []string{privKey}, SignRawTransactionOptions{})
+	signedRawTx = signed.Hex
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) SignRawTransactionWithKeys(rawTx string, privKeys []string, options SignRawTransactionOptions) (signed SignedRawTransaction, err error) {
+	return bitcoinRpc.SignRawTransactionWithKeysCtx(context.Background(), rawTx, privKeys, options)
+}
+
+// SignRawTransactionWithKeysCtx signs rawTx with privKeys. When some inputs
+// are left unsigned, it returns the result with an error wrapping
+// ErrIncompleteSignature and listing the errors of the inputs, unless
+// options.AllowIncomplete, e.g. for a transaction signed by several parties.
+func (bitcoinRpc BitcoinRpc) SignRawTransactionWithKeysCtx(ctx context.Context, rawTx string, privKeys []string, options SignRawTransactionOptions) (signed SignedRawTransaction, err error) {
+
+	if len(privKeys) == 0 {
+		err = fmt.Errorf("len(privKeys) == 0")
+		return
+	}
+	params := []interface{}{rawTx, privKeys}
+	switch options.SigHashType {
+	case "":
+		if len(options.PrevTxs) > 0 {
+			params = append(params, options.PrevTxs)
+		}
+	case SigHashDefault, SigHashAll, SigHashNone, SigHashSingle, SigHashAllAnyoneCanPay, SigHashNoneAnyoneCanPay, SigHashSingleAnyoneCanPay:
+		prevTxs := options.PrevTxs
+		if prevTxs == nil {
+			prevTxs = []PrevTx{}
+		}
+		params = append(params, prevTxs, options.SigHashType)
+	default:
+		err = fmt.Errorf("incorrect SigHashType[%s]", options.SigHashType)
+		return
+	}
 
 	jsonRpcInfo := defaultJsonRpcInfo()
 	jsonRpcInfo["method"] = "signrawtransactionwithkey"
-	jsonRpcInfo["params"] = []interface{}{rawTx, []string{privKey}}
+	jsonRpcInfo["params"] = params
 	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
 	if err != nil {
 		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
 		return
 	}
 
-	type signedRawTxInfo struct {
-		Hex      string `json:"hex"`      // (string) the transaction id
-		Complete bool   `json:"complete"` // (numeric) the vout value
+	type resultSignedRawTransaction struct {
+		Signed SignedRawTransaction `json:"result"`
 	}
-	type resultSignedRawTxInfo struct {
-		SignedRawTxInfo signedRawTxInfo `json:"result"`
-	}
-
-	// tmpBody := string(body)
-	// fmt.Printf("%s", tmpBody)
-
-	result := resultSignedRawTxInfo{}
+	result := resultSignedRawTransaction{}
 	err = json.Unmarshal(body, &result)
 	if err != nil {
 		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
 		return
 	}
 
-	signedRawTx = result.SignedRawTxInfo.Hex
-
+	signed = result.Signed
+	if !signed.Complete && !options.AllowIncomplete {
+		failures := []string{}
+		for _, signingError := range signed.Errors {
+			failures = append(failures, fmt.Sprintf("%s:%d: %s", signingError.TxID, signingError.Vout, signingError.Error))
+		}
+		err = fmt.Errorf("%w: %s", ErrIncompleteSignature, strings.Join(failures, "; "))
+		return
+	}
 	return
 }
 
 func (bitcoinRpc BitcoinRpc) SendRawTransaction(signedRawTx string) (txID string, err error) {
+	return bitcoinRpc.SendRawTransactionCtx(context.Background(), signedRawTx)
+}
+
+func (bitcoinRpc BitcoinRpc) SendRawTransactionCtx(ctx context.Context, signedRawTx string) (txID string, err error) {
 
 	jsonRpcInfo := defaultJsonRpcInfo()
 	jsonRpcInfo["method"] = "sendrawtransaction"
@@ -264,9 +462,9 @@ func (bitcoinRpc BitcoinRpc) SendRawTransaction(signedRawTx string) (txID string
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	body, err := bitcoinRpc.request(ctx, jsonRpcBytes)
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
+		err = fmt.Errorf("@bitcoinRpc.request(ctx, jsonRpcBytes): %w", err)
 		return
 	}
 
@@ -285,6 +483,10 @@ func (bitcoinRpc BitcoinRpc) SendRawTransaction(signedRawTx string) (txID string
 }
 
 func (bitcoinRpc BitcoinRpc) GetBlockCount() (blockCount int64, err error) {
+	return bitcoinRpc.GetBlockCountCtx(context.Background())
+}
+
+func (bitcoinRpc BitcoinRpc) GetBlockCountCtx(ctx context.Context) (blockCount int64, err error) {
 
 	jsonRpcInfo := defaultJsonRpcInfo()
 	jsonRpcInfo["method"] = "getblockcount"
@@ -295,9 +497,9 @@ func (bitcoinRpc BitcoinRpc) GetBlockCount() (blockCount int64, err error) {
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
 		return
 	}
 
@@ -316,6 +518,10 @@ func (bitcoinRpc BitcoinRpc) GetBlockCount() (blockCount int64, err error) {
 }
 
 func (bitcoinRpc BitcoinRpc) GetBlockHash(blockNumber int64) (blockHash string, err error) {
+	return bitcoinRpc.GetBlockHashCtx(context.Background(), blockNumber)
+}
+
+func (bitcoinRpc BitcoinRpc) GetBlockHashCtx(ctx context.Context, blockNumber int64) (blockHash string, err error) {
 
 	jsonRpcInfo := defaultJsonRpcInfo()
 	jsonRpcInfo["method"] = "getblockhash"
@@ -326,9 +532,9 @@ func (bitcoinRpc BitcoinRpc) GetBlockHash(blockNumber int64) (blockHash string,
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
 		return
 	}
 
@@ -346,8 +552,62 @@ func (bitcoinRpc BitcoinRpc) GetBlockHash(blockNumber int64) (blockHash string,
 	return
 }
 
+// BlockHeader holds the header fields which getblock and getblockheader share.
+type BlockHeader struct {
+	Hash              string  `json:"hash"`              // (string) the block hash (same as provided)
+	Confirmations     int64   `json:"confirmations"`     // (numeric) The number of confirmations, or -1 if the block is not on the main chain
+	Height            int64   `json:"height"`            // (numeric) The block height or index
+	Version           int32   `json:"version"`           // (numeric) The block version
+	VersionHex        string  `json:"versionHex"`        // (string) The block version formatted in hexadecimal
+	MerkleRoot        string  `json:"merkleroot"`        // (string) The merkle root
+	Time              int64   `json:"time"`              // (numeric) The block time expressed in UNIX epoch time
+	MedianTime        int64   `json:"mediantime"`        // (numeric) The median block time expressed in UNIX epoch time
+	Nonce             uint32  `json:"nonce"`             // (numeric) The nonce
+	Bits              string  `json:"bits"`              // (string) The bits
+	Difficulty        float64 `json:"difficulty"`        // (numeric) The difficulty
+	ChainWork         string  `json:"chainwork"`         // (string) Expected number of hashes required to produce the chain up to this block (in hex)
+	NTx               int64   `json:"nTx"`               // (numeric) The number of transactions in the block
+	PreviousBlockHash string  `json:"previousblockhash"` // (string) The hash of the previous block (if available)
+	NextBlockHash     string  `json:"nextblockhash"`     // (string) The hash of the next block (if available)
+}
+
+type Block struct {
+	BlockHeader
+	StrippedSize int64    `json:"strippedsize"` // (numeric) The block size excluding witness data
+	Size         int64    `json:"size"`         // (numeric) The block size
+	Weight       int64    `json:"weight"`       // (numeric) The block weight as defined in BIP 141
+	Tx           []string `json:"tx"`           // (json array) The transaction ids
+}
+
+// BlockWithTransactions is getblock with verbosity 2, or 3 for the prevouts of the inputs.
+type BlockWithTransactions struct {
+	BlockHeader
+	StrippedSize int64            `json:"strippedsize"` // (numeric) The block size excluding witness data
+	Size         int64            `json:"size"`         // (numeric) The block size
+	Weight       int64            `json:"weight"`       // (numeric) The block weight as defined in BIP 141
+	Tx           []RawTransaction `json:"tx"`           // (json array) The transactions, with their fee (and prevouts with verbosity 3) if undo data is available
+}
+
+// Deprecated: use GetBlockCtx, which returns a Block.
 func (bitcoinRpc BitcoinRpc) GetBlock(blockHash string) (block map[string]interface{}, err error) {
 
+	result, err := bitcoinRpc.GetBlockCtx(context.Background(), blockHash)
+	if err != nil {
+		return
+	}
+
+	block = make(map[string]interface{})
+	block["hash"] = result.Hash
+	block["height"] = result.Height
+	block["time"] = result.Time
+	block["tx"] = result.Tx
+	block["nTx"] = result.NTx
+
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetBlockCtx(ctx context.Context, blockHash string) (block Block, err error) {
+
 	jsonRpcInfo := defaultJsonRpcInfo()
 	jsonRpcInfo["method"] = "getblock"
 	jsonRpcInfo["params"] = []interface{}{blockHash}
@@ -357,22 +617,14 @@ func (bitcoinRpc BitcoinRpc) GetBlock(blockHash string) (block map[string]interf
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
 		return
 	}
 
-	type BlockInfo struct {
-		Hash   string   `json:"hash"`   // (string) the transaction id
-		Height int64    `json:"height"` // (numeric) the vout value
-		Time   int64    `json:"time"`   // (string) the bitcoin address
-		Tx     []string `json:"tx"`     // (string) The associated label, or "" for the default label
-		NTx    int64    `json:"nTx"`    // (string) the script key
-	}
-
 	type resultGetBlock struct {
-		Block BlockInfo `json:"result"`
+		Block Block `json:"result"`
 	}
 	result := resultGetBlock{}
 	err = json.Unmarshal(body, &result)
@@ -381,78 +633,158 @@ func (bitcoinRpc BitcoinRpc) GetBlock(blockHash string) (block map[string]interf
 		return
 	}
 
-	block = make(map[string]interface{})
-	block["hash"] = result.Block.Hash
-	block["height"] = result.Block.Height
-	block["time"] = result.Block.Time
-	block["tx"] = result.Block.Tx
-	block["nTx"] = result.Block.NTx
+	block = result.Block
 
 	return
 }
 
-func (bitcoinRpc BitcoinRpc) GetRawTransaction(txID string) (rawTxInfo map[string]interface{}, err error) {
+func (bitcoinRpc BitcoinRpc) GetBlockHex(blockHash string) (blockHex string, err error) {
+	return bitcoinRpc.GetBlockHexCtx(context.Background(), blockHash)
+}
 
-	rawTxInfo = make(map[string]interface{})
+// GetBlockHexCtx is getblock with verbosity 0: the serialized block, hex-encoded.
+func (bitcoinRpc BitcoinRpc) GetBlockHexCtx(ctx context.Context, blockHash string) (blockHex string, err error) {
 
 	jsonRpcInfo := defaultJsonRpcInfo()
-	jsonRpcInfo["method"] = "getrawtransaction"
-	jsonRpcInfo["params"] = []interface{}{txID, true}
+	jsonRpcInfo["method"] = "getblock"
+	jsonRpcInfo["params"] = []interface{}{blockHash, 0}
 	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
 	if err != nil {
 		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
 		return
 	}
 
-	type vin struct {
-		TxID string `json:"txid"`
-		Vout int    `json:"vout"`
+	type resultGetBlockHex struct {
+		BlockHex string `json:"result"`
 	}
-
-	type scriptPubKey struct {
-		Asm     string `json:"asm"`
-		Address string `json:"address"`
+	result := resultGetBlockHex{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
 	}
-	type vout struct {
-		Value        float64      `json:"value"`
-		N            int          `json:"n"`
-		ScriptPubKey scriptPubKey `json:"scriptPubKey"`
+
+	blockHex = result.BlockHex
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetBlockVerbosity(blockHash string, verbosity int) (block BlockWithTransactions, err error) {
+	return bitcoinRpc.GetBlockVerbosityCtx(context.Background(), blockHash, verbosity)
+}
+
+// GetBlockVerbosityCtx returns a block with its decoded transactions:
+// verbosity 2, or 3 for the prevouts too (Bitcoin Core 23+).
+func (bitcoinRpc BitcoinRpc) GetBlockVerbosityCtx(ctx context.Context, blockHash string, verbosity int) (block BlockWithTransactions, err error) {
+
+	if verbosity != 2 && verbosity != 3 {
+		err = fmt.Errorf("incorrect verbosity[%d]: use GetBlockHexCtx for 0 and GetBlockCtx for 1", verbosity)
+		return
 	}
 
-	type raxTxInfo struct {
-		InActiveChain bool   `json:"in_active_chain"` // (boolean) Whether specified block is in the active chain or not (only present with explicit "blockhash" argument)
-		Hex           string `json:"hex"`             // (string) The transaction hash (differs from txid for witness transactions)
-		TxID          string `json:"txid"`            // (string) The transaction id (same as provided)
-		Hash          string `json:"hash"`            // (string) The transaction hash (differs from txid for witness transactions)
-		Size          int64  `json:"size"`            // (numeric) The serialized transaction size
-		LockTime      int64  `json:"locktime"`        // (numeric) The lock time
-		Vin           []vin  `json:"vin"`
-		Vout          []vout `json:"vout"`
-		BlockHash     string `json:"blockhash"`     // (string) the block hash
-		Confirmations int    `json:"confirmations"` // (numeric) The confirmations
-		BlockTime     int64  `json:"blocktime"`     // (numeric) The block time expressed in UNIX epoch time
-		Time          int64  `json:"time"`          // (numeric) Same as "blocktime"
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "getblock"
+	jsonRpcInfo["params"] = []interface{}{blockHash, verbosity}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
 	}
 
-	type resultGetRawTx struct {
-		RaxTx raxTxInfo `json:"result"`
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
 	}
 
-	result := resultGetRawTx{}
+	type resultGetBlockVerbosity struct {
+		Block BlockWithTransactions `json:"result"`
+	}
+	result := resultGetBlockVerbosity{}
 	err = json.Unmarshal(body, &result)
 	if err != nil {
 		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
 		return
 	}
 
+	block = result.Block
+	return
+}
+
+type ScriptSig struct {
+	Asm string `json:"asm"` // (string) Disassembly of the signature script
+	Hex string `json:"hex"` // (string) The raw signature script bytes, hex-encoded
+}
+
+type Vin struct {
+	Coinbase    string    `json:"coinbase"`    // (string) The coinbase script, hex-encoded (only for coinbase transactions, without txid/vout/scriptSig)
+	TxID        string    `json:"txid"`        // (string) The transaction id
+	Vout        int       `json:"vout"`        // (numeric) The output number
+	ScriptSig   ScriptSig `json:"scriptSig"`   // (json object) The script
+	TxInWitness []string  `json:"txinwitness"` // (json array) hex-encoded witness data (if any)
+	Prevout     *Prevout  `json:"prevout"`     // (json object) The previous output (only with verbosity 2, if undo data is available)
+	Sequence    uint32    `json:"sequence"`    // (numeric) The script sequence number
+}
+
+func (vin Vin) IsCoinbase() bool {
+	return vin.Coinbase != ""
+}
+
+type Prevout struct {
+	Generated    bool         `json:"generated"`    // (boolean) Coinbase or not
+	Height       int64        `json:"height"`       // (numeric) The height of the prevout
+	Value        Amount       `json:"value"`        // (numeric) The value in BTC
+	ScriptPubKey ScriptPubKey `json:"scriptPubKey"` //
+}
+
+type ScriptPubKey struct {
+	Asm     string `json:"asm"`     // (string) Disassembly of the public key script
+	Desc    string `json:"desc"`    // (string) Inferred descriptor for the output
+	Hex     string `json:"hex"`     // (string) The raw public key script bytes, hex-encoded
+	Address string `json:"address"` // (string) The Bitcoin address (only if a well-defined address exists)
+	Type    string `json:"type"`    // (string) The type, eg 'pubkeyhash'
+}
+
+type Vout struct {
+	Value        Amount       `json:"value"`        // (numeric) The value in BTC
+	N            int          `json:"n"`            // (numeric) index
+	ScriptPubKey ScriptPubKey `json:"scriptPubKey"` //
+}
+
+type RawTransaction struct {
+	InActiveChain bool   `json:"in_active_chain"` // (boolean) Whether specified block is in the active chain or not (only present with explicit "blockhash" argument)
+	Hex           string `json:"hex"`             // (string) The serialized, hex-encoded data for 'txid'
+	TxID          string `json:"txid"`            // (string) The transaction id (same as provided)
+	Hash          string `json:"hash"`            // (string) The transaction hash (differs from txid for witness transactions)
+	Version       int32  `json:"version"`         // (numeric) The version
+	Size          int64  `json:"size"`            // (numeric) The serialized transaction size
+	VSize         int64  `json:"vsize"`           // (numeric) The virtual transaction size (differs from size for witness transactions)
+	Weight        int64  `json:"weight"`          // (numeric) The transaction's weight (between vsize*4-3 and vsize*4)
+	LockTime      int64  `json:"locktime"`        // (numeric) The lock time
+	Vin           []Vin  `json:"vin"`
+	Vout          []Vout `json:"vout"`
+	Fee           Amount `json:"fee"`           // (numeric) transaction fee in BTC (only with verbosity 2, if undo data is available, 0 otherwise)
+	BlockHash     string `json:"blockhash"`     // (string) the block hash
+	Confirmations int    `json:"confirmations"` // (numeric) The confirmations
+	BlockTime     int64  `json:"blocktime"`     // (numeric) The block time expressed in UNIX epoch time
+	Time          int64  `json:"time"`          // (numeric) Same as "blocktime"
+}
+
+// Deprecated: use GetRawTransactionCtx, which returns a RawTransaction.
+func (bitcoinRpc BitcoinRpc) GetRawTransaction(txID string) (rawTxInfo map[string]interface{}, err error) {
+
+	rawTx, err := bitcoinRpc.GetRawTransactionCtx(context.Background(), txID)
+	if err != nil {
+		return
+	}
+
 	tVins := make([]map[string]interface{}, 0)
-	for _, tRawVin := range result.RaxTx.Vin {
+	for _, tRawVin := range rawTx.Vin {
 		tVin := make(map[string]interface{})
 		tVin["txid"] = tRawVin.TxID
 		tVin["vout"] = tRawVin.Vout
@@ -461,32 +793,95 @@ func (bitcoinRpc BitcoinRpc) GetRawTransaction(txID string) (rawTxInfo map[strin
 	}
 
 	tVouts := make([]map[string]interface{}, 0)
-	for _, tRawVout := range result.RaxTx.Vout {
+	for _, tRawVout := range rawTx.Vout {
 		tVout := make(map[string]interface{})
 		tVout["address"] = tRawVout.ScriptPubKey.Address
 		tVout["n"] = tRawVout.N
-		tVout["value"] = tRawVout.Value
+		tVout["value"] = tRawVout.Value.BTC()
 		tVout["scriptPubKey"] = tRawVout.ScriptPubKey
 		tVouts = append(tVouts, tVout)
 	}
 
-	rawTxInfo["in_active_chain"] = result.RaxTx.InActiveChain
-	rawTxInfo["txid"] = result.RaxTx.TxID
-	rawTxInfo["hex"] = result.RaxTx.Hex
-	rawTxInfo["hash"] = result.RaxTx.Hash
-	rawTxInfo["size"] = result.RaxTx.Size
-	rawTxInfo["locktime"] = result.RaxTx.LockTime
+	rawTxInfo = make(map[string]interface{})
+	rawTxInfo["in_active_chain"] = rawTx.InActiveChain
+	rawTxInfo["txid"] = rawTx.TxID
+	rawTxInfo["hex"] = rawTx.Hex
+	rawTxInfo["hash"] = rawTx.Hash
+	rawTxInfo["size"] = rawTx.Size
+	rawTxInfo["locktime"] = rawTx.LockTime
 	rawTxInfo["vin"] = tVins
 	rawTxInfo["vout"] = tVouts
-	rawTxInfo["blockhash"] = result.RaxTx.BlockHash
-	rawTxInfo["confirmations"] = result.RaxTx.Confirmations
-	rawTxInfo["blocktime"] = result.RaxTx.BlockTime
-	rawTxInfo["time"] = result.RaxTx.Time
+	rawTxInfo["blockhash"] = rawTx.BlockHash
+	rawTxInfo["confirmations"] = rawTx.Confirmations
+	rawTxInfo["blocktime"] = rawTx.BlockTime
+	rawTxInfo["time"] = rawTx.Time
+
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetRawTransactionCtx(ctx context.Context, txID string) (rawTx RawTransaction, err error) {
+	return bitcoinRpc.GetRawTransactionVerbosityCtx(ctx, txID, 1, "")
+}
+
+func (bitcoinRpc BitcoinRpc) GetRawTransactionVerbosity(txID string, verbosity int, blockHash string) (rawTx RawTransaction, err error) {
+	return bitcoinRpc.GetRawTransactionVerbosityCtx(context.Background(), txID, verbosity, blockHash)
+}
+
+// GetRawTransactionVerbosityCtx calls getrawtransaction with verbosity 1, or 2
+// for the prevouts and the fee (Bitcoin Core 25+). With blockHash, the
+// transaction is looked up in that block, which works without -txindex.
+func (bitcoinRpc BitcoinRpc) GetRawTransactionVerbosityCtx(ctx context.Context, txID string, verbosity int, blockHash string) (rawTx RawTransaction, err error) {
+
+	params := []interface{}{txID, true}
+	switch verbosity {
+	case 1:
+		break
+	case 2:
+		params[1] = verbosity
+	default:
+		err = fmt.Errorf("incorrect verbosity[%d]", verbosity)
+		return
+	}
+	if blockHash != "" {
+		params = append(params, blockHash)
+	}
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "getrawtransaction"
+	jsonRpcInfo["params"] = params
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultGetRawTx struct {
+		RawTx RawTransaction `json:"result"`
+	}
+
+	result := resultGetRawTx{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	rawTx = result.RawTx
 
 	return
 }
 
 func (bitcoinRpc BitcoinRpc) GetNewAddress(walletName string, label string, addressType string) (newAddress string, err error) {
+	return bitcoinRpc.GetNewAddressCtx(context.Background(), walletName, label, addressType)
+}
+
+func (bitcoinRpc BitcoinRpc) GetNewAddressCtx(ctx context.Context, walletName string, label string, addressType string) (newAddress string, err error) {
 
 	bitcoinRpc.RpcPath = fmt.Sprintf("wallet/%s", walletName)
 	jsonRpcInfo := defaultJsonRpcInfo()
@@ -509,9 +904,9 @@ func (bitcoinRpc BitcoinRpc) GetNewAddress(walletName string, label string, addr
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	body, err := bitcoinRpc.request(ctx, jsonRpcBytes)
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
+		err = fmt.Errorf("@bitcoinRpc.request(ctx, jsonRpcBytes): %w", err)
 		return
 	}
 
@@ -530,8 +925,40 @@ func (bitcoinRpc BitcoinRpc) GetNewAddress(walletName string, label string, addr
 	return
 }
 
+type ReceivedByAddress struct {
+	InvolvesWatchOnly bool     `json:"involvesWatchonly"` // (boolean) Only returns true if imported addresses were involved in transaction
+	Address           string   `json:"address"`           // (string) The receiving address
+	Amount            Amount   `json:"amount"`            // (numeric) The total amount in BTC received by the address
+	Confirmations     int64    `json:"confirmations"`     // (numeric) The number of confirmations of the most recent transaction included
+	Label             string   `json:"label"`             // (string) The label of the receiving address. The default label is ""
+	TxIDs             []string `json:"txids"`             // (json array) The ids of transactions received with the address
+}
+
+// Deprecated: use ListReceivedByAddressCtx, which returns []ReceivedByAddress.
 func (bitcoinRpc BitcoinRpc) ListReceivedByAddress(walletName string, minconf int, includeEmpty bool, includeWatchonly bool, addressFilter string) (results []map[string]interface{}, err error) {
 
+	infos, err := bitcoinRpc.ListReceivedByAddressCtx(context.Background(), walletName, minconf, includeEmpty, includeWatchonly, addressFilter)
+	if err != nil {
+		return
+	}
+
+	results = make([]map[string]interface{}, 0)
+	for _, info := range infos {
+		tResult := make(map[string]interface{})
+		tResult["address"] = info.Address
+		tResult["amount"] = info.Amount.BTC()
+		tResult["confirmations"] = info.Confirmations
+		tResult["involvesWatchonly"] = info.InvolvesWatchOnly
+		tResult["label"] = info.Label
+		tResult["txids"] = info.TxIDs
+		results = append(results, tResult)
+	}
+
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) ListReceivedByAddressCtx(ctx context.Context, walletName string, minconf int, includeEmpty bool, includeWatchonly bool, addressFilter string) (results []ReceivedByAddress, err error) {
+
 	bitcoinRpc.RpcPath = fmt.Sprintf("wallet/%s", walletName)
 
 	jsonRpcInfo := defaultJsonRpcInfo()
@@ -543,42 +970,64 @@ func (bitcoinRpc BitcoinRpc) ListReceivedByAddress(walletName string, minconf in
 		return
 	}
 
-	body, err := bitcoinRpc.request(jsonRpcBytes)
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
 	if err != nil {
-		err = fmt.Errorf("@bitcoinRpc.request(jsonRpcBytes): %v", err)
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
 		return
 	}
 
-	type ListReceivedByAddressInfo struct {
-		InvolvesWatchOnly bool     `json:"involvesWatchonly"`
-		Address           string   `json:"address"`
-		Amount            float64  `json:"amount"`
-		Confirmations     int64    `json:"confirmations"`
-		Label             string   `json:"label"`
-		TxIDs             []string `json:"txids"`
-	}
-	type ResultListReceivedByAddress struct {
-		Infos []ListReceivedByAddressInfo `json:"result"`
+	type resultListReceivedByAddress struct {
+		Infos []ReceivedByAddress `json:"result"`
 	}
 
-	bodyResult := ResultListReceivedByAddress{}
+	bodyResult := resultListReceivedByAddress{}
 	err = json.Unmarshal(body, &bodyResult)
 	if err != nil {
 		err = fmt.Errorf("@json.Unmarshal(body, &bodyResult): %v", err)
 		return
 	}
 
-	results = make([]map[string]interface{}, 0)
-	for _, info := range bodyResult.Infos {
-		tResult := make(map[string]interface{})
-		tResult["address"] = info.Address
-		tResult["amount"] = info.Amount
-		tResult["confirmations"] = info.Confirmations
-		tResult["involvesWatchonly"] = info.InvolvesWatchOnly
-		tResult["label"] = info.Label
-		tResult["txids"] = info.TxIDs
-		results = append(results, tResult)
+	results = bodyResult.Infos
+	if results == nil {
+		results = make([]ReceivedByAddress, 0)
+	}
+
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) ListWallets() (walletNames []string, err error) {
+	return bitcoinRpc.ListWalletsCtx(context.Background())
+}
+
+func (bitcoinRpc BitcoinRpc) ListWalletsCtx(ctx context.Context) (walletNames []string, err error) {
+
+	bitcoinRpc.RpcPath = ""
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "listwallets"
+	jsonRpcInfo["params"] = []interface{}{}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultListWallets struct {
+		WalletNames []string `json:"result"`
+	}
+	result := resultListWallets{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
 	}
 
+	walletNames = result.WalletNames
 	return
 }
@@ -1,43 +1,65 @@
 package gobitcoinclilight
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
 )
 
+func newTestBitcoinRpc(server *bitcoindtest.Server) BitcoinRpc {
+	return BitcoinRpc{
+		RpcUser:        server.RpcUser,
+		RpcPW:          server.RpcPW,
+		RpcConnect:     server.Host(),
+		RpcPort:        server.Port(),
+		RpcRetryPolicy: &NoRetry,
+	}
+}
+
 func TestListUnspentOfAddress(t *testing.T) {
 
-	bitcoinRpc := BitcoinRpc{
-		RpcUser:    "ideajoo",
-		RpcPW:      "ideajoo123",
-		RpcConnect: "127.0.0.1",
-		RpcPort:    "18332",
-		RpcPath:    "wallet/test_07",
-	}
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+
+	bitcoinRpc := newTestBitcoinRpc(server)
+	bitcoinRpc.RpcPath = "wallet/test_07"
 
 	result, err := bitcoinRpc.ListUnspentOfAddress(0, 0, []string{"tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh", "tb1qmhqe8pr06v0mefelardj4h6hkq095e5dh72mv3"})
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		t.Fatal(err)
+	}
+	if len(result) != 2 || result[0]["amount"].(float64) != 0.00015 || result[1]["vout"].(float64) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+
+	request := server.RequestsFor("listunspent")[0]
+	addresses := make([]string, 0)
+	if err = request.Param(2, &addresses); err != nil || len(addresses) != 2 || request.Path != "/wallet/test_07" {
+		t.Fatalf("unexpected request %+v", request)
 	}
 
 	jsonString, err := json.Marshal(result)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		t.Fatal(err)
 	}
-	fmt.Printf("\n\n== result ==\n%s\n", jsonString)
+	t.Logf("\n== result ==\n%s\n", jsonString)
 }
 
 func TestCreateRawTransaction(t *testing.T) {
 
-	bitcoinRpc := BitcoinRpc{
-		RpcUser:    "ideajoo",
-		RpcPW:      "ideajoo123",
-		RpcConnect: "127.0.0.1",
-		RpcPort:    "18332",
-	}
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
 
 	inTxUnspents := make([]map[string]interface{}, 0)
 
@@ -51,184 +73,460 @@ func TestCreateRawTransaction(t *testing.T) {
 	tmpUnspent["vout"] = 1
 	inTxUnspents = append(inTxUnspents, tmpUnspent)
 
-	outAddress := make(map[string]float64)
-	outAddress["tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh"] = 0.00002000
-	outAddress["tb1q3flg4mlnuk2xexu773g8d4lh6nl48rc6w6vhsm"] = 0.00003000
+	outAddress := make(map[string]Amount)
+	outAddress["tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh"] = 2000 * Satoshi
+	outAddress["tb1q3flg4mlnuk2xexu773g8d4lh6nl48rc6w6vhsm"] = 3000 * Satoshi
 
 	outDataHex := "48454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874" // "HELLO ideajoo/go-bitcoin-cli-light"
 
 	result, err := bitcoinRpc.CreateRawTransaction(inTxUnspents, outAddress, outDataHex)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		t.Fatal(err)
+	}
+	if result != bitcoindtest.FixtureRawTx {
+		t.Fatalf("unexpected rawTx %s", result)
 	}
 
-	fmt.Printf("\n\n== result rawTx ==\n%+v\n", result)
-	// 020000000244199d95b6dc4eb1d6b7dc9dddf9f092751fa41ea739d3c46b32b69b9f0beab00100000000fdffffff55a4a5010bca54b6fdd507cf9850c95142a2fab14db7ec7530b2bba76f6579980100000000fdffffff020000000000000000246a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874c05d0000000000001600143938a2e285bff79dc6f96a8e9a96d54c6ce7586c00000000
+	outputs := make([]map[string]interface{}, 0)
+	if err = server.RequestsFor("createrawtransaction")[0].Param(1, &outputs); err != nil || len(outputs) != 3 || outputs[2]["data"] != outDataHex {
+		t.Fatalf("unexpected outputs %+v: %v", outputs, err)
+	}
+	if params := string(server.RequestsFor("createrawtransaction")[0].Params[1]); !strings.Contains(params, `"tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh":0.00002000`) {
+		t.Fatalf("amounts must be sent as exact decimals: %s", params)
+	}
 }
 
 func TestDumpPrivateKey(t *testing.T) {
-	bitcoinRpc := BitcoinRpc{
-		RpcUser:    "ideajoo",
-		RpcPW:      "ideajoo123",
-		RpcConnect: "127.0.0.1",
-		RpcPort:    "18332",
-	}
-	tAddress := "tb1q8yu29c59hlmem3hed28f49k4f3kwwkrv4smgkh"
-	resultPrivKey, err := bitcoinRpc.DumpPrivateKey(tAddress)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	resultPrivKey, err := bitcoinRpc.DumpPrivateKey(bitcoindtest.FixtureAddress)
+	if err != nil || resultPrivKey != bitcoindtest.FixturePrivKey {
+		t.Fatalf("unexpected privKey %s: %v", resultPrivKey, err)
 	}
-	fmt.Printf("\n\n== result ==\n%s\n", resultPrivKey)
-	// cQLN8Z38G7MJk82JMFbuQcXSfQGHeZKshWJ4haSmnb9AxX9Et4Vy
 }
 
 func TestSignRawTransactionWithKey(t *testing.T) {
-	bitcoinRpc := BitcoinRpc{
-		RpcUser:    "ideajoo",
-		RpcPW:      "ideajoo123",
-		RpcConnect: "127.0.0.1",
-		RpcPort:    "18332",
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	resultSignedRawTx, err := bitcoinRpc.SignRawTransactionWithKey(bitcoindtest.FixtureRawTx, bitcoindtest.FixturePrivKey)
+	if err != nil || resultSignedRawTx != bitcoindtest.FixtureSignedRawTx {
+		t.Fatalf("unexpected signedRawTx %s: %v", resultSignedRawTx, err)
 	}
-	tRawTx := "020000000244199d95b6dc4eb1d6b7dc9dddf9f092751fa41ea739d3c46b32b69b9f0beab00100000000fdffffff55a4a5010bca54b6fdd507cf9850c95142a2fab14db7ec7530b2bba76f6579980100000000fdffffff020000000000000000246a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874c05d0000000000001600143938a2e285bff79dc6f96a8e9a96d54c6ce7586c00000000"
-	tPrivKey := "cQLN8Z38G7MJk82JMFbuQcXSfQGHeZKshWJ4haSmnb9AxX9Et4Vy"
-	resultSignedRawTx, err := bitcoinRpc.SignRawTransactionWithKey(tRawTx, tPrivKey)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+	params := []json.RawMessage{}
+	if err = server.RequestsFor("signrawtransactionwithkey")[0].Param(1, &params); err != nil || len(params) != 1 {
+		t.Fatalf("unexpected privKeys %s: %v", params, err)
+	}
+
+	server.SetResult("signrawtransactionwithkey", json.RawMessage(`{
+  "hex": "`+bitcoindtest.FixtureRawTx+`",
+  "complete": false,
+  "errors": [
+    {
+      "txid": "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944",
+      "vout": 1,
+      "witness": [],
+      "scriptSig": "",
+      "sequence": 4294967293,
+      "error": "Unable to sign input, invalid stack size (possibly missing key)"
+    }
+  ]
+}`))
+	if _, err = bitcoinRpc.SignRawTransactionWithKey(bitcoindtest.FixtureRawTx, bitcoindtest.FixturePrivKey); !errors.Is(err, ErrIncompleteSignature) {
+		t.Fatalf("expected ErrIncompleteSignature, got %v", err)
+	}
+
+	prevTxs := []PrevTx{{TxID: "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944", Vout: 1, ScriptPubKey: "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c", Amount: 12000}}
+	options := SignRawTransactionOptions{PrevTxs: prevTxs, SigHashType: SigHashAll}
+	signed, err := bitcoinRpc.SignRawTransactionWithKeys(bitcoindtest.FixtureRawTx, []string{bitcoindtest.FixturePrivKey, bitcoindtest.FixturePrivKey}, options)
+	if !errors.Is(err, ErrIncompleteSignature) || signed.Complete || len(signed.Errors) != 1 || signed.Errors[0].Sequence != SequenceRBF || signed.Errors[0].Vout != 1 {
+		t.Fatalf("unexpected signed %+v: %v", signed, err)
+	}
+	options.AllowIncomplete = true
+	signed, err = bitcoinRpc.SignRawTransactionWithKeys(bitcoindtest.FixtureRawTx, []string{bitcoindtest.FixturePrivKey}, options)
+	if err != nil || signed.Hex != bitcoindtest.FixtureRawTx || len(signed.Errors) != 1 {
+		t.Fatalf("unexpected signed %+v: %v", signed, err)
+	}
+	request := server.RequestsFor("signrawtransactionwithkey")[3]
+	sentPrevTxs, sigHashType := []map[string]interface{}{}, ""
+	if request.Param(2, &sentPrevTxs) != nil || request.Param(3, &sigHashType) != nil || len(sentPrevTxs) != 1 || sentPrevTxs[0]["amount"] != 0.00012 || sigHashType != SigHashAll {
+		t.Fatalf("unexpected params %s", request.Params)
+	}
+	if _, hasRedeemScript := sentPrevTxs[0]["redeemScript"]; hasRedeemScript {
+		t.Fatalf("unexpected params %s", request.Params)
+	}
+
+	for _, incorrect := range []SignRawTransactionOptions{{SigHashType: "all"}} {
+		if _, err = bitcoinRpc.SignRawTransactionWithKeys(bitcoindtest.FixtureRawTx, []string{bitcoindtest.FixturePrivKey}, incorrect); err == nil {
+			t.Fatalf("expected an error for %+v", incorrect)
+		}
+	}
+	if _, err = bitcoinRpc.SignRawTransactionWithKeys(bitcoindtest.FixtureRawTx, nil, SignRawTransactionOptions{}); err == nil {
+		t.Fatalf("expected an error without privKeys")
 	}
-	fmt.Printf("\n\n== result ==\n%s\n", resultSignedRawTx)
-	// 0200000000010244199d95b6dc4eb1d6b7dc9dddf9f092751fa41ea739d3c46b32b69b9f0beab00100000000fdffffff55a4a5010bca54b6fdd507cf9850c95142a2fab14db7ec7530b2bba76f6579980100000000fdffffff020000000000000000246a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874c05d0000000000001600143938a2e285bff79dc6f96a8e9a96d54c6ce7586c02473044022032b8e51b0e6be0846f2bd458919e3dad85d3923afce20ff6c3494a63eb88014002204c136999d2a60f23e12bbaa5f5a1e9e0704c00441defd77bb7c55ce86a538f4c01210307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b520247304402203800d79251b9eaf995549ee9c64c43a46fa33071a43dcac76f6d9328e67e2177022008ec36403a89ec8bbbea163932bd4048c93431336a6c0f94db6c749b631304ee01210307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b5200000000
 }
 
 func TestSendRawTransaction(t *testing.T) {
-	bitcoinRpc := BitcoinRpc{
-		RpcUser:    "ideajoo",
-		RpcPW:      "ideajoo123",
-		RpcConnect: "127.0.0.1",
-		RpcPort:    "18332",
-	}
-	tSignedRawTx := "0200000000010244199d95b6dc4eb1d6b7dc9dddf9f092751fa41ea739d3c46b32b69b9f0beab00100000000fdffffff55a4a5010bca54b6fdd507cf9850c95142a2fab14db7ec7530b2bba76f6579980100000000fdffffff020000000000000000246a2248454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874c05d0000000000001600143938a2e285bff79dc6f96a8e9a96d54c6ce7586c02473044022032b8e51b0e6be0846f2bd458919e3dad85d3923afce20ff6c3494a63eb88014002204c136999d2a60f23e12bbaa5f5a1e9e0704c00441defd77bb7c55ce86a538f4c01210307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b520247304402203800d79251b9eaf995549ee9c64c43a46fa33071a43dcac76f6d9328e67e2177022008ec36403a89ec8bbbea163932bd4048c93431336a6c0f94db6c749b631304ee01210307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b5200000000"
-	resultTxID, err := bitcoinRpc.SendRawTransaction(tSignedRawTx)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	resultTxID, err := bitcoinRpc.SendRawTransaction(bitcoindtest.FixtureSignedRawTx)
+	if err != nil || resultTxID != bitcoindtest.FixtureTxID {
+		t.Fatalf("unexpected txID %s: %v", resultTxID, err)
 	}
-	fmt.Printf("\n\n== result ==\n%s\n", resultTxID)
-	// fb92e4a2aab9e55f11dfe3bf047a8d37fde0b274e99cee08db943f12f6975788
 }
 
 func TestGetBlockCount(t *testing.T) {
-	bitcoinRpc := BitcoinRpc{
-		RpcUser:    "ideajoo",
-		RpcPW:      "ideajoo123",
-		RpcConnect: "127.0.0.1",
-		RpcPort:    "18332",
-	}
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
 	resultBlockCount, err := bitcoinRpc.GetBlockCount()
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+	if err != nil || resultBlockCount != bitcoindtest.FixtureBlockCount {
+		t.Fatalf("unexpected blockCount %d: %v", resultBlockCount, err)
 	}
-	fmt.Printf("\n\n== result ==\n%d\n", resultBlockCount)
-	// 2344981
 }
 
 func TestGetBlockHash(t *testing.T) {
-	bitcoinRpc := BitcoinRpc{
-		RpcUser:    "ideajoo",
-		RpcPW:      "ideajoo123",
-		RpcConnect: "127.0.0.1",
-		RpcPort:    "18332",
-	}
-	resultBlockHash, err := bitcoinRpc.GetBlockHash(2344981)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	resultBlockHash, err := bitcoinRpc.GetBlockHash(bitcoindtest.FixtureBlockCount)
+	if err != nil || resultBlockHash != bitcoindtest.FixtureBlockHash {
+		t.Fatalf("unexpected blockHash %s: %v", resultBlockHash, err)
 	}
-	fmt.Printf("\n\n== result ==\n%s\n", resultBlockHash)
-	// 000000000000e7f3e8f60f9431725df65cdeb5c13386f03edaba73269e2d313d
 }
 
 func TestGetBlock(t *testing.T) {
-	bitcoinRpc := BitcoinRpc{
-		RpcUser:    "ideajoo",
-		RpcPW:      "ideajoo123",
-		RpcConnect: "127.0.0.1",
-		RpcPort:    "18332",
-	}
-	resultBlock, err := bitcoinRpc.GetBlock("000000000000e7f3e8f60f9431725df65cdeb5c13386f03edaba73269e2d313d")
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	resultBlock, err := bitcoinRpc.GetBlock(bitcoindtest.FixtureBlockHash)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		t.Fatal(err)
 	}
-	fmt.Printf("\n\n== result ==\n%+v\n", resultBlock["tx"])
-	for _, txid := range resultBlock["tx"].([]string) {
-		fmt.Printf("\n== txid :%s", txid)
+	txIDs := resultBlock["tx"].([]string)
+	if len(txIDs) != 2 || txIDs[1] != bitcoindtest.FixtureTxID || resultBlock["height"].(int64) != bitcoindtest.FixtureBlockCount {
+		t.Fatalf("unexpected block %+v", resultBlock)
 	}
 }
 
 func TestGetRawTransaction(t *testing.T) {
-	bitcoinRpc := BitcoinRpc{
-		RpcUser:    "ideajoo",
-		RpcPW:      "ideajoo123",
-		RpcConnect: "127.0.0.1",
-		RpcPort:    "18332",
-	}
-	result, err := bitcoinRpc.GetRawTransaction("45c0f0a58d4f356b605a26b8aceb3faab24cf067c7d084b252d4ff863c692771")
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	result, err := bitcoinRpc.GetRawTransaction(bitcoindtest.FixtureTxID)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		t.Fatal(err)
+	}
+	if result["confirmations"].(int) != 1 || result["vout"].([]map[string]interface{})[1]["address"] != bitcoindtest.FixtureAddress {
+		t.Fatalf("unexpected rawTxInfo %+v", result)
 	}
 
 	jsonString, err := json.Marshal(result)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		t.Fatal(err)
 	}
-	fmt.Printf("\n\n== result ==\n%s\n", jsonString)
+	t.Logf("\n== result ==\n%s\n", jsonString)
 }
 
-func TestGetNewAddress(t *testing.T) {
+func TestGetRawTransactionCtx(t *testing.T) {
 
-	bitcoinRpc := BitcoinRpc{
-		RpcUser:    "ideajoo",
-		RpcPW:      "ideajoo123",
-		RpcConnect: "127.0.0.1",
-		RpcPort:    "18332",
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	rawTx, err := bitcoinRpc.GetRawTransactionCtx(context.Background(), bitcoindtest.FixtureTxID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rawTx.TxID != bitcoindtest.FixtureTxID || len(rawTx.Vin) != 2 || rawTx.Vin[0].Vout != 1 {
+		t.Fatalf("unexpected rawTx %+v", rawTx)
+	}
+	if rawTx.Vout[1].Value != 24000 || rawTx.Vout[1].ScriptPubKey.Address != bitcoindtest.FixtureAddress {
+		t.Fatalf("unexpected vout %+v", rawTx.Vout[1])
 	}
+}
+
+func TestGetNewAddress(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
 
 	tWallet := "test"
 	resultNewAddress, err := bitcoinRpc.GetNewAddress(tWallet, "", "")
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+	if err != nil || resultNewAddress != "tb1qa6v5vvpagj7lqnummqff0jm086y3vq3jjc9r90" {
+		t.Fatalf("unexpected newAddress %s: %v", resultNewAddress, err)
+	}
+	if path := server.RequestsFor("getnewaddress")[0].Path; path != "/wallet/test" {
+		t.Fatalf("unexpected path %s", path)
+	}
+
+	if _, err = bitcoinRpc.GetNewAddress(tWallet, "", "p2tr-ish"); err == nil {
+		t.Fatalf("expected an error for an incorrect addressType")
 	}
-	fmt.Printf("\n\n== result ==\n%s\n", resultNewAddress)
-	// tb1qa6v5vvpagj7lqnummqff0jm086y3vq3jjc9r90
 }
 
 func TestListReceivedByAddress(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	tWallet := "test"
+	resultListReceivedByAddress, err := bitcoinRpc.ListReceivedByAddress(tWallet, 1, true, true, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resultListReceivedByAddress) != 1 || resultListReceivedByAddress[0]["amount"].(float64) != 0.00025 {
+		t.Fatalf("unexpected result %+v", resultListReceivedByAddress)
+	}
+
+	jsonString, err := json.Marshal(resultListReceivedByAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("\n== result ==\n%s\n", jsonString)
+}
+
+func TestIPv6RpcConnect(t *testing.T) {
+
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("no IPv6 loopback: %v", err)
+	}
+	host := ""
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		w.Write([]byte(`{"result":2344981,"error":null,"id":null}`))
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
 	bitcoinRpc := BitcoinRpc{
 		RpcUser:    "ideajoo",
 		RpcPW:      "ideajoo123",
-		RpcConnect: "127.0.0.1",
-		RpcPort:    "18332",
+		RpcConnect: "::1",
+		RpcPort:    strconv.Itoa(listener.Addr().(*net.TCPAddr).Port),
+	}
+	blockCount, err := bitcoinRpc.GetBlockCount()
+	if err != nil || blockCount != 2344981 || host != listener.Addr().String() {
+		t.Fatalf("unexpected blockCount %d from host %s: %v", blockCount, host, err)
 	}
+}
 
-	tWallet := "test"
-	resultListReceivedByAddress, err := bitcoinRpc.ListReceivedByAddress(tWallet, 1, true, true, "")
+func TestGetBlockCountCtxDeadline(t *testing.T) {
+
+	hung := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-hung:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(hung)
+
+	serverURL, _ := url.Parse(server.URL)
+	bitcoinRpc := BitcoinRpc{
+		RpcUser:    "ideajoo",
+		RpcPW:      "ideajoo123",
+		RpcConnect: serverURL.Hostname(),
+		RpcPort:    serverURL.Port(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := bitcoinRpc.GetBlockCountCtx(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	bitcoinRpc.RpcTimeout = 50 * time.Millisecond
+	bitcoinRpc.RpcRetryPolicy = &NoRetry
+	_, err = bitcoinRpc.GetBlockCount()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded with RpcTimeout, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = bitcoinRpc.GetBlockCountCtx(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestRPCError(t *testing.T) {
+
+	server := bitcoindtest.NewServer()
+	defer server.Close()
+	server.SetError("dumpprivkey", -5, "Invalid address")
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	privKey, err := bitcoinRpc.DumpPrivateKey("invalid")
+	if !errors.Is(err, ErrInvalidAddressOrKey) {
+		t.Fatalf("expected ErrInvalidAddressOrKey, got %q, %v", privKey, err)
+	}
+	rpcError := &RPCError{}
+	if !errors.As(err, &rpcError) || rpcError.Code != -5 || rpcError.Message != "Invalid address" {
+		t.Fatalf("unexpected RPCError %+v", rpcError)
+	}
+	if errors.Is(err, ErrWalletNotFound) {
+		t.Fatalf("ErrInvalidAddressOrKey must not match ErrWalletNotFound")
+	}
+
+	if _, err = bitcoinRpc.GetBlockCount(); !errors.Is(err, ErrMethodNotFound) {
+		t.Fatalf("expected ErrMethodNotFound, got %v", err)
+	}
+
+	bitcoinRpc.RpcUser = "nobody"
+	_, err = bitcoinRpc.GetBlockCount()
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestGetRawTransactionVerbosity(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	server.Handle("getrawtransaction", func(request bitcoindtest.Request) (interface{}, *bitcoindtest.Error) {
+		verbosity := 0
+		if request.Param(1, &verbosity) == nil && verbosity == 2 {
+			return bitcoindtest.GetRawTransactionVerbosity2Fixture, nil
+		}
+		return bitcoindtest.GetRawTransactionFixture, nil
+	})
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	rawTx, err := bitcoinRpc.GetRawTransactionCtx(context.Background(), bitcoindtest.FixtureTxID)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		t.Fatal(err)
+	}
+	if rawTx.Version != 2 || rawTx.VSize != 222 || rawTx.Weight != 888 || rawTx.Fee != 0 || rawTx.Vin[0].Prevout != nil {
+		t.Fatalf("unexpected rawTx %+v", rawTx)
+	}
+	vin := rawTx.Vin[1]
+	if vin.IsCoinbase() || vin.Sequence != SequenceRBF || len(vin.TxInWitness) != 2 || vin.ScriptSig.Hex != "" {
+		t.Fatalf("unexpected vin %+v", vin)
+	}
+	scriptPubKey := rawTx.Vout[1].ScriptPubKey
+	if scriptPubKey.Hex != "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c" || scriptPubKey.Type != "witness_v0_keyhash" || scriptPubKey.Desc == "" {
+		t.Fatalf("unexpected scriptPubKey %+v", scriptPubKey)
+	}
+	if params := server.RequestsFor("getrawtransaction")[0].Params; len(params) != 2 || string(params[1]) != "true" {
+		t.Fatalf("unexpected params %s", params)
 	}
 
-	jsonString, err := json.Marshal(resultListReceivedByAddress)
+	rawTx, err = bitcoinRpc.GetRawTransactionVerbosity(bitcoindtest.FixtureTxID, 2, bitcoindtest.FixtureBlockHash)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		t.Fatal(err)
+	}
+	prevoutValue := Amount(0)
+	for _, vin := range rawTx.Vin {
+		if vin.Prevout == nil || vin.Prevout.Generated || vin.Prevout.ScriptPubKey.Address != bitcoindtest.FixtureAddress {
+			t.Fatalf("unexpected prevout %+v", vin.Prevout)
+		}
+		prevoutValue += vin.Prevout.Value
+	}
+	if rawTx.Fee != 1000 || prevoutValue-rawTx.Vout[1].Value != rawTx.Fee {
+		t.Fatalf("unexpected fee %s, prevouts %s", rawTx.Fee, prevoutValue)
+	}
+	blockHash := ""
+	request := server.RequestsFor("getrawtransaction")[1]
+	if string(request.Params[1]) != "2" || request.Param(2, &blockHash) != nil || blockHash != bitcoindtest.FixtureBlockHash {
+		t.Fatalf("unexpected params %s", request.Params)
 	}
-	fmt.Printf("\n\n== result ==\n%s\n", jsonString)
 
+	if _, err = bitcoinRpc.GetRawTransactionVerbosity(bitcoindtest.FixtureTxID, 0, ""); err == nil {
+		t.Fatalf("expected an error for verbosity 0")
+	}
+
+	server.SetResult("getrawtransaction", json.RawMessage(`{"txid": "0d4dfb4a3e2d0b21a9b37ab3b3a9c0b9e0bbd7bdb08d37b5cbb2a16bd8c3bb4e", "vin": [{"coinbase": "03157c23", "sequence": 4294967295}], "vout": []}`))
+	rawTx, err = bitcoinRpc.GetRawTransactionVerbosity("0d4dfb4a3e2d0b21a9b37ab3b3a9c0b9e0bbd7bdb08d37b5cbb2a16bd8c3bb4e", 1, bitcoindtest.FixtureBlockHash)
+	if err != nil || !rawTx.Vin[0].IsCoinbase() || rawTx.Vin[0].Sequence != SequenceFinal {
+		t.Fatalf("unexpected coinbase %+v: %v", rawTx, err)
+	}
+}
+
+func TestGetBlockVerbosity(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	server.Handle("getblock", func(request bitcoindtest.Request) (interface{}, *bitcoindtest.Error) {
+		verbosity := 1
+		request.Param(1, &verbosity)
+		switch verbosity {
+		case 0:
+			return "0000002031", nil
+		case 1:
+			return bitcoindtest.GetBlockFixture, nil
+		}
+		return bitcoindtest.GetBlockVerbosity2Fixture, nil
+	})
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	block, err := bitcoinRpc.GetBlockCtx(context.Background(), bitcoindtest.FixtureBlockHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Hash != bitcoindtest.FixtureBlockHash || block.PreviousBlockHash != "0000000000000118aebf3eb6d2c9ab1b5e9f5cd1fe10cb6f1a1d1c3ba40d2bd1" || block.NextBlockHash != "" {
+		t.Fatalf("unexpected block %+v", block)
+	}
+	if block.Bits != "1a01a3c9" || block.Nonce != 3436563209 || block.MedianTime != 1665896214 || block.Version != 536870912 || block.Weight != 1896 || block.Confirmations != 1 || block.Difficulty < 10241079 {
+		t.Fatalf("unexpected header %+v", block.BlockHeader)
+	}
+
+	blockHex, err := bitcoinRpc.GetBlockHex(bitcoindtest.FixtureBlockHash)
+	if err != nil || blockHex != "0000002031" {
+		t.Fatalf("unexpected blockHex %s: %v", blockHex, err)
+	}
+
+	blockWithTxs, err := bitcoinRpc.GetBlockVerbosity(bitcoindtest.FixtureBlockHash, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blockWithTxs.Height != bitcoindtest.FixtureBlockCount || len(blockWithTxs.Tx) != 2 || int64(len(blockWithTxs.Tx)) != blockWithTxs.NTx {
+		t.Fatalf("unexpected block %+v", blockWithTxs)
+	}
+	coinbaseTx, tx := blockWithTxs.Tx[0], blockWithTxs.Tx[1]
+	if !coinbaseTx.Vin[0].IsCoinbase() || coinbaseTx.Vout[0].Value != 4883789 {
+		t.Fatalf("unexpected coinbase %+v", coinbaseTx)
+	}
+	if tx.TxID != bitcoindtest.FixtureTxID || tx.Fee != 1000 || tx.Hex != bitcoindtest.FixtureSignedRawTx || tx.Vout[1].ScriptPubKey.Address != bitcoindtest.FixtureAddress {
+		t.Fatalf("unexpected tx %+v", tx)
+	}
+
+	verbosities := make([]string, 0)
+	for _, request := range server.RequestsFor("getblock") {
+		if len(request.Params) > 1 {
+			verbosities = append(verbosities, string(request.Params[1]))
+		}
+	}
+	if len(verbosities) != 2 || verbosities[0] != "0" || verbosities[1] != "2" {
+		t.Fatalf("unexpected verbosities %v", verbosities)
+	}
+
+	for _, verbosity := range []int{0, 1, 4} {
+		if _, err = bitcoinRpc.GetBlockVerbosity(bitcoindtest.FixtureBlockHash, verbosity); err == nil {
+			t.Fatalf("expected an error for verbosity %d", verbosity)
+		}
+	}
 }
@@ -0,0 +1,158 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+const medianTimeSpan = 11
+
+var (
+	ErrHeaderNotConnected = errors.New("header does not connect to the tip")
+	ErrHeaderTimeTooOld   = errors.New("header time not after the median time past")
+)
+
+// pow limits of the networks, as in bitcoind's chainparams; MainnetPowLimit is
+// also the limit of testnet3 and testnet4
+var (
+	MainnetPowLimit, _ = new(big.Int).SetString("00000000ffffffffffffffffffffffffffffffffffffffffffffffffffffffff", 16)
+	SignetPowLimit, _  = new(big.Int).SetString("00000377ae000000000000000000000000000000000000000000000000000000", 16)
+	RegtestPowLimit, _ = new(big.Int).SetString("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", 16)
+)
+
+// HeaderChain tracks block headers from a trusted one (e.g. a checkpoint) and
+// accepts a header only if it connects to the tip, has a valid proof of work
+// for its bits, and is newer than the median time past. Not knowing the headers
+// before a start other than the genesis, it checks the median time past once
+// 11 headers are tracked. It doesn't check the difficulty adjustments, so it
+// sanity-checks a node rather than replacing one.
+type HeaderChain struct {
+	PowLimit *big.Int // nil skips the check of the targets against a limit
+
+	mutex       sync.RWMutex
+	startHeight int64
+	headers     []RawBlockHeader
+	hashes      []Hash
+}
+
+func NewHeaderChain(startHeight int64, start RawBlockHeader, powLimit *big.Int) (chain *HeaderChain) {
+	return &HeaderChain{
+		PowLimit:    powLimit,
+		startHeight: startHeight,
+		headers:     []RawBlockHeader{start},
+		hashes:      []Hash{start.BlockHash()},
+	}
+}
+
+func (chain *HeaderChain) TipHeight() int64 {
+
+	chain.mutex.RLock()
+	defer chain.mutex.RUnlock()
+
+	return chain.startHeight + int64(len(chain.headers)) - 1
+}
+
+func (chain *HeaderChain) Tip() (height int64, hash Hash) {
+
+	chain.mutex.RLock()
+	defer chain.mutex.RUnlock()
+
+	return chain.startHeight + int64(len(chain.headers)) - 1, chain.hashes[len(chain.hashes)-1]
+}
+
+// Header returns the header at height, if tracked.
+func (chain *HeaderChain) Header(height int64) (header RawBlockHeader, ok bool) {
+
+	chain.mutex.RLock()
+	defer chain.mutex.RUnlock()
+
+	index := height - chain.startHeight
+	if index < 0 || index >= int64(len(chain.headers)) {
+		return
+	}
+	return chain.headers[index], true
+}
+
+// MedianTimePast is the median time of the last 11 headers (or fewer right after the start).
+func (chain *HeaderChain) MedianTimePast() uint32 {
+
+	chain.mutex.RLock()
+	defer chain.mutex.RUnlock()
+
+	return chain.medianTimePast()
+}
+
+func (chain *HeaderChain) medianTimePast() uint32 {
+
+	first := len(chain.headers) - medianTimeSpan
+	if first < 0 {
+		first = 0
+	}
+	times := make([]uint32, 0, medianTimeSpan)
+	for _, header := range chain.headers[first:] {
+		times = append(times, header.Time)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+	return times[len(times)/2]
+}
+
+// Add verifies header and appends it as the new tip.
+func (chain *HeaderChain) Add(header RawBlockHeader) (err error) {
+
+	chain.mutex.Lock()
+	defer chain.mutex.Unlock()
+
+	height := chain.startHeight + int64(len(chain.headers))
+	tipHash := chain.hashes[len(chain.hashes)-1]
+	if header.PrevBlock != tipHash {
+		err = fmt.Errorf("%w: height %d, prev %s instead of %s", ErrHeaderNotConnected, height, header.PrevBlock, tipHash)
+		return
+	}
+	if err = header.CheckProofOfWork(chain.PowLimit); err != nil {
+		err = fmt.Errorf("height %d: %w", height, err)
+		return
+	}
+	// a median of fewer headers than bitcoind's would reject valid headers
+	checkTime := len(chain.headers) >= medianTimeSpan || chain.startHeight == 0
+	if medianTimePast := chain.medianTimePast(); checkTime && header.Time <= medianTimePast {
+		err = fmt.Errorf("%w: height %d, time %d <= %d", ErrHeaderTimeTooOld, height, header.Time, medianTimePast)
+		return
+	}
+
+	chain.headers = append(chain.headers, header)
+	chain.hashes = append(chain.hashes, header.BlockHash())
+	return
+}
+
+// Sync fetches and adds the headers after the tip from bitcoinRpc, up to its
+// block count or maxHeaders (0 means no limit), and returns how many were added.
+func (chain *HeaderChain) Sync(ctx context.Context, bitcoinRpc BitcoinRpc, maxHeaders int) (added int, err error) {
+
+	blockCount, err := bitcoinRpc.GetBlockCountCtx(ctx)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.GetBlockCountCtx(ctx): %w", err)
+		return
+	}
+
+	for height := chain.TipHeight() + 1; height <= blockCount && (maxHeaders <= 0 || added < maxHeaders); height++ {
+		blockHash, errHash := bitcoinRpc.GetBlockHashCtx(ctx, height)
+		if errHash != nil {
+			err = fmt.Errorf("@bitcoinRpc.GetBlockHashCtx(ctx, %d): %w", height, errHash)
+			return
+		}
+		header, errHeader := bitcoinRpc.GetRawBlockHeaderCtx(ctx, blockHash)
+		if errHeader != nil {
+			err = fmt.Errorf("@bitcoinRpc.GetRawBlockHeaderCtx(ctx, %s): %w", blockHash, errHeader)
+			return
+		}
+		if err = chain.Add(header); err != nil {
+			return
+		}
+		added++
+	}
+	return
+}
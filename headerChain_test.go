@@ -0,0 +1,124 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+// mineRegtestHeader finds a nonce for the easy regtest target.
+func mineRegtestHeader(t *testing.T, prev Hash, time uint32) (header RawBlockHeader) {
+
+	header = RawBlockHeader{Version: 0x20000000, PrevBlock: prev, Time: time, Bits: 0x207fffff}
+	for ; header.CheckProofOfWork(RegtestPowLimit) != nil; header.Nonce++ {
+		if header.Nonce > 1000 {
+			t.Fatalf("no nonce found")
+		}
+	}
+	return
+}
+
+func TestHeaderChain(t *testing.T) {
+
+	genesis, _ := DecodeRawBlockHeaderHex(genesisHeaderHex)
+	header1, _ := DecodeRawBlockHeaderHex(header1Hex)
+	header2, _ := DecodeRawBlockHeaderHex(header2Hex)
+
+	chain := NewHeaderChain(0, genesis, MainnetPowLimit)
+	if err := chain.Add(header2); !errors.Is(err, ErrHeaderNotConnected) {
+		t.Fatalf("expected ErrHeaderNotConnected, got %v", err)
+	}
+	for _, header := range []RawBlockHeader{header1, header2} {
+		if err := chain.Add(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	height, hash := chain.Tip()
+	if height != 2 || hash.String() != header2Hash || chain.MedianTimePast() != header1.Time {
+		t.Fatalf("unexpected tip %d %s", height, hash)
+	}
+	if header, ok := chain.Header(1); !ok || header != header1 {
+		t.Fatalf("unexpected header %+v", header)
+	}
+	if _, ok := chain.Header(3); ok {
+		t.Fatalf("unexpected header at height 3")
+	}
+
+	forged := header2
+	forged.PrevBlock = hash
+	forged.Time += 600
+	if err := chain.Add(forged); !errors.Is(err, ErrProofOfWork) {
+		t.Fatalf("expected ErrProofOfWork, got %v", err)
+	}
+
+	// median time past over the last 11 headers, on regtest difficulty
+	start := mineRegtestHeader(t, Hash{}, 1000)
+	regtestChain := NewHeaderChain(100, start, RegtestPowLimit)
+	for i, time := range []uint32{1010, 1020, 1015, 1030, 1025, 1040, 1050, 1045, 1060, 1070} {
+		_, tip := regtestChain.Tip()
+		if err := regtestChain.Add(mineRegtestHeader(t, tip, time)); err != nil {
+			t.Fatalf("header %d: %v", i, err)
+		}
+	}
+	// times sorted: 1000 1010 1015 1020 1025 1030 1040 1045 1050 1060 1070
+	if medianTimePast := regtestChain.MedianTimePast(); medianTimePast != 1030 {
+		t.Fatalf("unexpected median time past %d", medianTimePast)
+	}
+	_, tip := regtestChain.Tip()
+	if err := regtestChain.Add(mineRegtestHeader(t, tip, 1030)); !errors.Is(err, ErrHeaderTimeTooOld) {
+		t.Fatalf("expected ErrHeaderTimeTooOld, got %v", err)
+	}
+	if err := regtestChain.Add(mineRegtestHeader(t, tip, 1031)); err != nil || regtestChain.TipHeight() != 111 {
+		t.Fatalf("unexpected add %v at %d", err, regtestChain.TipHeight())
+	}
+
+	// a header older than its parent is valid; right after a start other than
+	// the genesis, the median time past is unknown
+	regtestChain = NewHeaderChain(100, start, RegtestPowLimit)
+	if err := regtestChain.Add(mineRegtestHeader(t, start.BlockHash(), 990)); err != nil {
+		t.Fatal(err)
+	}
+	regtestChain = NewHeaderChain(0, start, RegtestPowLimit)
+	if err := regtestChain.Add(mineRegtestHeader(t, start.BlockHash(), 990)); !errors.Is(err, ErrHeaderTimeTooOld) {
+		t.Fatalf("expected ErrHeaderTimeTooOld after the genesis, got %v", err)
+	}
+}
+
+func TestHeaderChainSync(t *testing.T) {
+
+	server := bitcoindtest.NewServer()
+	defer server.Close()
+	hashes := []string{genesisHash, header1Hash, header2Hash}
+	headers := map[string]string{genesisHash: genesisHeaderHex, header1Hash: header1Hex, header2Hash: header2Hex}
+	server.SetResult("getblockcount", 2)
+	server.Handle("getblockhash", func(request bitcoindtest.Request) (interface{}, *bitcoindtest.Error) {
+		height := 0
+		request.Param(0, &height)
+		return hashes[height], nil
+	})
+	server.Handle("getblockheader", func(request bitcoindtest.Request) (interface{}, *bitcoindtest.Error) {
+		blockHash := ""
+		request.Param(0, &blockHash)
+		return headers[blockHash], nil
+	})
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	genesis, _ := DecodeRawBlockHeaderHex(genesisHeaderHex)
+	chain := NewHeaderChain(0, genesis, MainnetPowLimit)
+	added, err := chain.Sync(context.Background(), bitcoinRpc, 1)
+	if err != nil || added != 1 || chain.TipHeight() != 1 {
+		t.Fatalf("unexpected sync %d: %v", added, err)
+	}
+	added, err = chain.Sync(context.Background(), bitcoinRpc, 0)
+	if err != nil || added != 1 || chain.TipHeight() != 2 {
+		t.Fatalf("unexpected sync %d: %v", added, err)
+	}
+
+	// a node serving a header of another chain
+	chain = NewHeaderChain(0, mineRegtestHeader(t, Hash{}, 1000), RegtestPowLimit)
+	if _, err = chain.Sync(context.Background(), bitcoinRpc, 0); !errors.Is(err, ErrHeaderNotConnected) {
+		t.Fatalf("expected ErrHeaderNotConnected, got %v", err)
+	}
+}
@@ -0,0 +1,387 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type MempoolInfo struct {
+	Loaded              bool   `json:"loaded"`              // (boolean) True if the initial load attempt of the persisted mempool finished
+	Size                int64  `json:"size"`                // (numeric) Current tx count
+	Bytes               int64  `json:"bytes"`               // (numeric) Sum of all virtual transaction sizes as defined in BIP 141
+	Usage               int64  `json:"usage"`               // (numeric) Total memory usage for the mempool
+	TotalFee            Amount `json:"total_fee"`           // (numeric) Total fees for the mempool in BTC, ignoring modified fees through prioritisetransaction
+	MaxMempool          int64  `json:"maxmempool"`          // (numeric) Maximum memory usage for the mempool
+	MempoolMinFee       Amount `json:"mempoolminfee"`       // (numeric) Minimum fee rate in BTC/kvB for tx to be accepted
+	MinRelayTxFee       Amount `json:"minrelaytxfee"`       // (numeric) Current minimum relay fee for transactions
+	IncrementalRelayFee Amount `json:"incrementalrelayfee"` // (numeric) minimum fee rate increment for mempool limiting or replacement in BTC/kvB
+	UnbroadcastCount    int64  `json:"unbroadcastcount"`    // (numeric) Current number of transactions that haven't passed initial broadcast yet
+	FullRBF             bool   `json:"fullrbf"`             // (boolean) True if the mempool accepts RBF without replaceability signaling inspection
+}
+
+type MempoolEntryFees struct {
+	Base       Amount `json:"base"`       // (numeric) transaction fee, denominated in BTC
+	Modified   Amount `json:"modified"`   // (numeric) transaction fee with fee deltas used for mining priority, denominated in BTC
+	Ancestor   Amount `json:"ancestor"`   // (numeric) transaction fees of in-mempool ancestors (including this one) with fee deltas used for mining priority, denominated in BTC
+	Descendant Amount `json:"descendant"` // (numeric) transaction fees of in-mempool descendants (including this one) with fee deltas used for mining priority, denominated in BTC
+}
+
+type MempoolEntry struct {
+	TxID              string           `json:"-"`                  // the key of the entry in getrawmempool, set by the verbose calls
+	VSize             int64            `json:"vsize"`              // (numeric) virtual transaction size as defined in BIP 141
+	Weight            int64            `json:"weight"`             // (numeric) transaction weight as defined in BIP 141
+	Time              int64            `json:"time"`               // (numeric) local time transaction entered pool in seconds since 1 Jan 1970 GMT
+	Height            int64            `json:"height"`             // (numeric) block height when transaction entered pool
+	DescendantCount   int64            `json:"descendantcount"`    // (numeric) number of in-mempool descendant transactions (including this one)
+	DescendantSize    int64            `json:"descendantsize"`     // (numeric) virtual transaction size of in-mempool descendants (including this one)
+	AncestorCount     int64            `json:"ancestorcount"`      // (numeric) number of in-mempool ancestor transactions (including this one)
+	AncestorSize      int64            `json:"ancestorsize"`       // (numeric) virtual transaction size of in-mempool ancestors (including this one)
+	WTxID             string           `json:"wtxid"`              // (string) hash of serialized transaction, including witness data
+	Fees              MempoolEntryFees `json:"fees"`               // (json object)
+	Depends           []string         `json:"depends"`            // (json array) unconfirmed transactions used as inputs for this transaction
+	SpentBy           []string         `json:"spentby"`            // (json array) unconfirmed transactions spending outputs from this transaction
+	BIP125Replaceable bool             `json:"bip125-replaceable"` // (boolean) Whether this transaction signals BIP125 replaceability or has an unconfirmed ancestor signaling BIP125 replaceability
+	Unbroadcast       bool             `json:"unbroadcast"`        // (boolean) Whether this transaction is currently unbroadcast (initial broadcast not yet acknowledged by any peers)
+}
+
+// FeeRate is the fee rate a miner ranks the entry with: its own, or the one
+// of its package with the unconfirmed ancestors when lower.
+func (entry MempoolEntry) FeeRate() FeeRate {
+
+	feeRate := FeeRateOf(entry.Fees.Modified, entry.VSize)
+	if entry.AncestorSize > 0 {
+		if ancestorFeeRate := FeeRateOf(entry.Fees.Ancestor, entry.AncestorSize); ancestorFeeRate < feeRate {
+			feeRate = ancestorFeeRate
+		}
+	}
+	return feeRate
+}
+
+func (bitcoinRpc BitcoinRpc) GetMempoolInfo() (info MempoolInfo, err error) {
+	return bitcoinRpc.GetMempoolInfoCtx(context.Background())
+}
+
+func (bitcoinRpc BitcoinRpc) GetMempoolInfoCtx(ctx context.Context) (info MempoolInfo, err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "getmempoolinfo"
+	jsonRpcInfo["params"] = []interface{}{}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultGetMempoolInfo struct {
+		Info MempoolInfo `json:"result"`
+	}
+	result := resultGetMempoolInfo{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	info = result.Info
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetRawMempool() (txIDs []string, err error) {
+	return bitcoinRpc.GetRawMempoolCtx(context.Background())
+}
+
+func (bitcoinRpc BitcoinRpc) GetRawMempoolCtx(ctx context.Context) (txIDs []string, err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "getrawmempool"
+	jsonRpcInfo["params"] = []interface{}{false}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultGetRawMempool struct {
+		TxIDs []string `json:"result"`
+	}
+	result := resultGetRawMempool{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	txIDs = result.TxIDs
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetRawMempoolSequence() (txIDs []string, mempoolSequence uint64, err error) {
+	return bitcoinRpc.GetRawMempoolSequenceCtx(context.Background())
+}
+
+// GetRawMempoolSequenceCtx returns the txids with the mempool sequence they
+// are valid at, to resume from the "sequence" notifications of zmq.
+func (bitcoinRpc BitcoinRpc) GetRawMempoolSequenceCtx(ctx context.Context) (txIDs []string, mempoolSequence uint64, err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "getrawmempool"
+	jsonRpcInfo["params"] = []interface{}{false, true}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultGetRawMempoolSequence struct {
+		Result struct {
+			TxIDs           []string `json:"txids"`
+			MempoolSequence uint64   `json:"mempool_sequence"`
+		} `json:"result"`
+	}
+	result := resultGetRawMempoolSequence{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	txIDs, mempoolSequence = result.Result.TxIDs, result.Result.MempoolSequence
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetRawMempoolVerbose() (entries map[string]MempoolEntry, err error) {
+	return bitcoinRpc.GetRawMempoolVerboseCtx(context.Background())
+}
+
+func (bitcoinRpc BitcoinRpc) GetRawMempoolVerboseCtx(ctx context.Context) (entries map[string]MempoolEntry, err error) {
+	err = bitcoinRpc.requestMempoolEntries(ctx, "getrawmempool", []interface{}{true}, &entries)
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetMempoolEntry(txID string) (entry MempoolEntry, err error) {
+	return bitcoinRpc.GetMempoolEntryCtx(context.Background(), txID)
+}
+
+func (bitcoinRpc BitcoinRpc) GetMempoolEntryCtx(ctx context.Context, txID string) (entry MempoolEntry, err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "getmempoolentry"
+	jsonRpcInfo["params"] = []interface{}{txID}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultGetMempoolEntry struct {
+		Entry MempoolEntry `json:"result"`
+	}
+	result := resultGetMempoolEntry{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	entry = result.Entry
+	entry.TxID = txID
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetMempoolAncestors(txID string) (txIDs []string, err error) {
+	return bitcoinRpc.GetMempoolAncestorsCtx(context.Background(), txID)
+}
+
+func (bitcoinRpc BitcoinRpc) GetMempoolAncestorsCtx(ctx context.Context, txID string) (txIDs []string, err error) {
+	err = bitcoinRpc.requestMempoolTxIDs(ctx, "getmempoolancestors", txID, &txIDs)
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetMempoolAncestorsVerbose(txID string) (entries map[string]MempoolEntry, err error) {
+	return bitcoinRpc.GetMempoolAncestorsVerboseCtx(context.Background(), txID)
+}
+
+func (bitcoinRpc BitcoinRpc) GetMempoolAncestorsVerboseCtx(ctx context.Context, txID string) (entries map[string]MempoolEntry, err error) {
+	err = bitcoinRpc.requestMempoolEntries(ctx, "getmempoolancestors", []interface{}{txID, true}, &entries)
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetMempoolDescendants(txID string) (txIDs []string, err error) {
+	return bitcoinRpc.GetMempoolDescendantsCtx(context.Background(), txID)
+}
+
+func (bitcoinRpc BitcoinRpc) GetMempoolDescendantsCtx(ctx context.Context, txID string) (txIDs []string, err error) {
+	err = bitcoinRpc.requestMempoolTxIDs(ctx, "getmempooldescendants", txID, &txIDs)
+	return
+}
+
+func (bitcoinRpc BitcoinRpc) GetMempoolDescendantsVerbose(txID string) (entries map[string]MempoolEntry, err error) {
+	return bitcoinRpc.GetMempoolDescendantsVerboseCtx(context.Background(), txID)
+}
+
+func (bitcoinRpc BitcoinRpc) GetMempoolDescendantsVerboseCtx(ctx context.Context, txID string) (entries map[string]MempoolEntry, err error) {
+	err = bitcoinRpc.requestMempoolEntries(ctx, "getmempooldescendants", []interface{}{txID, true}, &entries)
+	return
+}
+
+// requestMempoolTxIDs is the non verbose getmempoolancestors or getmempooldescendants of txID.
+func (bitcoinRpc BitcoinRpc) requestMempoolTxIDs(ctx context.Context, method string, txID string, txIDs *[]string) (err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = method
+	jsonRpcInfo["params"] = []interface{}{txID, false}
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultMempoolTxIDs struct {
+		TxIDs []string `json:"result"`
+	}
+	result := resultMempoolTxIDs{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	*txIDs = result.TxIDs
+	return
+}
+
+// requestMempoolEntries calls a verbose mempool method, which returns the
+// entries by txid, and sets the TxID of every entry.
+func (bitcoinRpc BitcoinRpc) requestMempoolEntries(ctx context.Context, method string, params []interface{}, entries *map[string]MempoolEntry) (err error) {
+
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = method
+	jsonRpcInfo["params"] = params
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultMempoolEntries struct {
+		Entries map[string]MempoolEntry `json:"result"`
+	}
+	result := resultMempoolEntries{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	*entries = make(map[string]MempoolEntry, len(result.Entries))
+	for txID, entry := range result.Entries {
+		entry.TxID = txID
+		(*entries)[txID] = entry
+	}
+	return
+}
+
+type MempoolAcceptFees struct {
+	Base              Amount   `json:"base"`               // (numeric) transaction fee in BTC
+	EffectiveFeeRate  Amount   `json:"effective-feerate"`  // (numeric) the effective feerate in BTC per KvB. May differ from the base feerate if, for example, there are modified fees from prioritisetransaction or a package feerate was used.
+	EffectiveIncludes []string `json:"effective-includes"` // (json array) transactions whose fees and vsizes are included in effective-feerate.
+}
+
+type MempoolAcceptResult struct {
+	TxID          string             `json:"txid"`           // (string) The transaction hash in hex
+	WTxID         string             `json:"wtxid"`          // (string) The transaction witness hash in hex
+	PackageError  string             `json:"package-error"`  // (string, optional) Package validation error, if any (only possible if rawtxs had more than 1 transaction).
+	Allowed       bool               `json:"allowed"`        // (boolean, optional) Whether this tx would be accepted to the mempool and pass client-specified maxfeerate. If not present, the tx was not fully validated due to a failure in another tx in the list.
+	VSize         int64              `json:"vsize"`          // (numeric, optional) Virtual transaction size as defined in BIP 141. This is different from actual serialized size for witness transactions as witness data is discounted (only present when 'allowed' is true)
+	Fees          *MempoolAcceptFees `json:"fees"`           // (json object, optional) Transaction fees (only present if 'allowed' is true)
+	RejectReason  string             `json:"reject-reason"`  // (string, optional) Rejection reason (only present when 'allowed' is false)
+	RejectDetails string             `json:"reject-details"` // (string, optional) Rejection details (only present when 'allowed' is false and rejection details exist)
+}
+
+func (bitcoinRpc BitcoinRpc) TestMempoolAccept(signedRawTxs []string, maxFeeRate Amount) (results []MempoolAcceptResult, err error) {
+	return bitcoinRpc.TestMempoolAcceptCtx(context.Background(), signedRawTxs, maxFeeRate)
+}
+
+// TestMempoolAcceptCtx checks the transactions (a package when several) against
+// the mempool without broadcasting them. maxFeeRate is in BTC/kvB; zero keeps
+// the default of bitcoind (0.10 BTC/kvB).
+func (bitcoinRpc BitcoinRpc) TestMempoolAcceptCtx(ctx context.Context, signedRawTxs []string, maxFeeRate Amount) (results []MempoolAcceptResult, err error) {
+
+	if len(signedRawTxs) == 0 {
+		err = fmt.Errorf("len(signedRawTxs) == 0")
+		return
+	}
+	if maxFeeRate < 0 || !maxFeeRate.IsValid() {
+		err = fmt.Errorf("incorrect maxFeeRate[%s]", maxFeeRate)
+		return
+	}
+
+	params := []interface{}{signedRawTxs}
+	if maxFeeRate > 0 {
+		params = append(params, maxFeeRate)
+	}
+	jsonRpcInfo := defaultJsonRpcInfo()
+	jsonRpcInfo["method"] = "testmempoolaccept"
+	jsonRpcInfo["params"] = params
+	jsonRpcBytes, err := json.Marshal(jsonRpcInfo)
+	if err != nil {
+		err = fmt.Errorf("@json.Marshal(jsonRpcInfo): %v", err)
+		return
+	}
+
+	body, err := bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.requestIdempotent(ctx, jsonRpcBytes): %w", err)
+		return
+	}
+
+	type resultTestMempoolAccept struct {
+		Results []MempoolAcceptResult `json:"result"`
+	}
+	result := resultTestMempoolAccept{}
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		err = fmt.Errorf("@json.Unmarshal(body, &result): %v", err)
+		return
+	}
+
+	results = result.Results
+	return
+}
@@ -0,0 +1,136 @@
+package gobitcoinclilight
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+func TestGetMempoolInfo(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	info, err := bitcoinRpc.GetMempoolInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Loaded || info.Size != 2 || info.TotalFee != 2410 || info.MempoolMinFee != 1000 || !info.FullRBF {
+		t.Fatalf("unexpected info %+v", info)
+	}
+}
+
+func TestGetRawMempool(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	server.Handle("getrawmempool", func(request bitcoindtest.Request) (interface{}, *bitcoindtest.Error) {
+		verbose, mempoolSequence := false, false
+		request.Param(0, &verbose)
+		request.Param(1, &mempoolSequence)
+		switch {
+		case verbose:
+			return map[string]json.RawMessage{bitcoindtest.FixtureTxID: bitcoindtest.GetMempoolEntryFixture}, nil
+		case mempoolSequence:
+			return map[string]interface{}{"txids": []string{bitcoindtest.FixtureTxID}, "mempool_sequence": 4242}, nil
+		}
+		return []string{bitcoindtest.FixtureTxID, bitcoindtest.FixtureChildTxID}, nil
+	})
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	txIDs, err := bitcoinRpc.GetRawMempool()
+	if err != nil || len(txIDs) != 2 || txIDs[1] != bitcoindtest.FixtureChildTxID {
+		t.Fatalf("unexpected txIDs %v: %v", txIDs, err)
+	}
+
+	txIDs, mempoolSequence, err := bitcoinRpc.GetRawMempoolSequence()
+	if err != nil || len(txIDs) != 1 || mempoolSequence != 4242 {
+		t.Fatalf("unexpected txIDs %v at %d: %v", txIDs, mempoolSequence, err)
+	}
+
+	entries, err := bitcoinRpc.GetRawMempoolVerbose()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := entries[bitcoindtest.FixtureTxID]
+	if len(entries) != 1 || entry.TxID != bitcoindtest.FixtureTxID || entry.VSize != 222 || entry.Fees.Base != 1000 || entry.Fees.Descendant != 2410 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
+
+func TestGetMempoolEntry(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	server.SetResult("getmempoolancestors", []string{})
+	server.Handle("getmempooldescendants", func(request bitcoindtest.Request) (interface{}, *bitcoindtest.Error) {
+		verbose := false
+		request.Param(1, &verbose)
+		if verbose {
+			return map[string]interface{}{bitcoindtest.FixtureChildTxID: map[string]interface{}{"vsize": 141, "fees": map[string]interface{}{"base": 0.0000141}, "depends": []string{bitcoindtest.FixtureTxID}}}, nil
+		}
+		return []string{bitcoindtest.FixtureChildTxID}, nil
+	})
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	entry, err := bitcoinRpc.GetMempoolEntry(bitcoindtest.FixtureTxID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.TxID != bitcoindtest.FixtureTxID || entry.WTxID == "" || !entry.BIP125Replaceable || len(entry.SpentBy) != 1 || entry.AncestorCount != 1 {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+
+	ancestors, err := bitcoinRpc.GetMempoolAncestors(bitcoindtest.FixtureTxID)
+	if err != nil || len(ancestors) != 0 {
+		t.Fatalf("unexpected ancestors %v: %v", ancestors, err)
+	}
+	descendants, err := bitcoinRpc.GetMempoolDescendants(bitcoindtest.FixtureTxID)
+	if err != nil || len(descendants) != 1 || descendants[0] != entry.SpentBy[0] {
+		t.Fatalf("unexpected descendants %v: %v", descendants, err)
+	}
+	descendantEntries, err := bitcoinRpc.GetMempoolDescendantsVerbose(bitcoindtest.FixtureTxID)
+	child := descendantEntries[bitcoindtest.FixtureChildTxID]
+	if err != nil || child.TxID != bitcoindtest.FixtureChildTxID || child.Fees.Base+entry.Fees.Base != entry.Fees.Descendant {
+		t.Fatalf("unexpected descendants %+v: %v", descendantEntries, err)
+	}
+
+	request := server.RequestsFor("getmempooldescendants")[1]
+	verbose := false
+	if err = request.Param(1, &verbose); err != nil || !verbose {
+		t.Fatalf("unexpected params %s", request.Params)
+	}
+}
+
+func TestTestMempoolAccept(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	bitcoinRpc := newTestBitcoinRpc(server)
+
+	results, err := bitcoinRpc.TestMempoolAccept([]string{bitcoindtest.FixtureSignedRawTx}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].Allowed || results[0].TxID != bitcoindtest.FixtureTxID || results[0].Fees == nil || results[0].Fees.EffectiveFeeRate != 4504 {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if params := server.RequestsFor("testmempoolaccept")[0].Params; len(params) != 1 {
+		t.Fatalf("unexpected params %s", params)
+	}
+
+	server.SetResult("testmempoolaccept", []map[string]interface{}{{"txid": bitcoindtest.FixtureTxID, "allowed": false, "reject-reason": "max-fee-exceeded"}})
+	results, err = bitcoinRpc.TestMempoolAccept([]string{bitcoindtest.FixtureSignedRawTx}, 1000)
+	if err != nil || results[0].Allowed || results[0].RejectReason != "max-fee-exceeded" || results[0].Fees != nil {
+		t.Fatalf("unexpected results %+v: %v", results, err)
+	}
+	if params := server.RequestsFor("testmempoolaccept")[1].Params; len(params) != 2 || string(params[1]) != "0.00001000" {
+		t.Fatalf("unexpected params %s", params)
+	}
+
+	if _, err = bitcoinRpc.TestMempoolAccept(nil, 0); err == nil {
+		t.Fatalf("expected an error without transactions")
+	}
+}
@@ -0,0 +1,449 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrNoHealthyNode = errors.New("no healthy node in the pool")
+
+// NodePool spreads calls over several bitcoind nodes: reads go to the healthy
+// node out of initial block download with the most blocks and fail over to the next one on node failures,
+// then to the unhealthy ones as a last resort; wallet calls go to the node
+// owning the wallet.
+type NodePool struct {
+	mutex       sync.RWMutex
+	nodes       []*poolNode
+	walletNodes map[string]int // walletName -> index in nodes
+}
+
+type poolNode struct {
+	bitcoinRpc           BitcoinRpc
+	healthy              bool
+	blockCount           int64
+	headers              int64
+	initialBlockDownload bool
+	lastError            error
+	lastCheck            time.Time
+}
+
+type NodeStatus struct {
+	Index                int
+	RpcConnect           string
+	RpcPort              string
+	Healthy              bool
+	BlockCount           int64
+	Headers              int64
+	InitialBlockDownload bool
+	LastError            error
+	LastCheck            time.Time
+}
+
+// NewNodePool considers every node healthy until the first CheckHealth.
+func NewNodePool(bitcoinRpcs ...BitcoinRpc) (pool *NodePool, err error) {
+
+	if len(bitcoinRpcs) == 0 {
+		err = fmt.Errorf("len(bitcoinRpcs) == 0")
+		return
+	}
+
+	pool = &NodePool{walletNodes: make(map[string]int)}
+	for _, bitcoinRpc := range bitcoinRpcs {
+		pool.nodes = append(pool.nodes, &poolNode{bitcoinRpc: bitcoinRpc, healthy: true})
+	}
+	return
+}
+
+// CheckHealth queries getblockchaininfo on every node concurrently.
+func (pool *NodePool) CheckHealth(ctx context.Context) {
+
+	waitGroup := sync.WaitGroup{}
+	for index := range pool.nodes {
+		waitGroup.Add(1)
+		go func(index int) {
+			defer waitGroup.Done()
+			pool.mutex.RLock()
+			bitcoinRpc := pool.nodes[index].bitcoinRpc
+			pool.mutex.RUnlock()
+
+			info, err := bitcoinRpc.GetBlockchainInfoCtx(ctx)
+
+			pool.mutex.Lock()
+			defer pool.mutex.Unlock()
+			node := pool.nodes[index]
+			node.healthy = err == nil
+			node.lastError = err
+			node.lastCheck = time.Now()
+			if err == nil {
+				node.blockCount = info.Blocks
+				node.headers = info.Headers
+				node.initialBlockDownload = info.InitialBlockDownload
+			}
+		}(index)
+	}
+	waitGroup.Wait()
+}
+
+// Run calls CheckHealth every interval until ctx is done.
+func (pool *NodePool) Run(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		pool.CheckHealth(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (pool *NodePool) Status() (statuses []NodeStatus) {
+
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	for index, node := range pool.nodes {
+		statuses = append(statuses, NodeStatus{
+			Index:                index,
+			RpcConnect:           node.bitcoinRpc.RpcConnect,
+			RpcPort:              node.bitcoinRpc.RpcPort,
+			Healthy:              node.healthy,
+			BlockCount:           node.blockCount,
+			Headers:              node.headers,
+			InitialBlockDownload: node.initialBlockDownload,
+			LastError:            node.lastError,
+			LastCheck:            node.lastCheck,
+		})
+	}
+	return
+}
+
+// candidates returns the healthy nodes, then the unhealthy ones; each out of
+// initial block download first, then by blocks and headers.
+func (pool *NodePool) candidates() (indexes []int) {
+
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	for index := range pool.nodes {
+		indexes = append(indexes, index)
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		nodeI, nodeJ := pool.nodes[indexes[i]], pool.nodes[indexes[j]]
+		if nodeI.healthy != nodeJ.healthy {
+			return nodeI.healthy
+		}
+		if nodeI.initialBlockDownload != nodeJ.initialBlockDownload {
+			return !nodeI.initialBlockDownload
+		}
+		if nodeI.blockCount != nodeJ.blockCount {
+			return nodeI.blockCount > nodeJ.blockCount
+		}
+		return nodeI.headers > nodeJ.headers
+	})
+	return
+}
+
+func (pool *NodePool) markFailed(index int, err error) {
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.nodes[index].healthy = false
+	pool.nodes[index].lastError = err
+}
+
+// markAnswered makes a node answering again healthy before the next CheckHealth.
+func (pool *NodePool) markAnswered(index int) {
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if !pool.nodes[index].healthy {
+		pool.nodes[index].healthy = true
+		pool.nodes[index].lastError = nil
+	}
+}
+
+// isNodeFailure tells a broken, busy or hanging node (worth trying another one)
+// apart from an answer which every node would give, like an invalid address.
+// A deadline exceeded is of the node, e.g. of its RpcTimeout, once Do checked
+// that the caller's ctx is alive.
+func isNodeFailure(err error) bool {
+
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	rpcError := &RPCError{}
+	if errors.As(err, &rpcError) {
+		return errors.Is(err, ErrInWarmup) || errors.Is(err, ErrClientInInitialDownload)
+	}
+	return true
+}
+
+// Best returns the first candidate: a healthy node if any, preferably out of
+// initial block download, or else an unhealthy one, which may have recovered
+// since.
+func (pool *NodePool) Best() (bitcoinRpc BitcoinRpc, err error) {
+
+	indexes := pool.candidates()
+	if len(indexes) == 0 {
+		err = ErrNoHealthyNode
+		return
+	}
+
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+	bitcoinRpc = pool.nodes[indexes[0]].bitcoinRpc
+	return
+}
+
+// Do runs fn on the best node and fails over to the next candidate while fn
+// returns node failures; such nodes are marked unhealthy until the next
+// CheckHealth, or until they answer again as a last resort. It stops once ctx
+// is done, without blaming the node.
+func (pool *NodePool) Do(ctx context.Context, fn func(bitcoinRpc BitcoinRpc) error) (err error) {
+
+	indexes := pool.candidates()
+	if len(indexes) == 0 {
+		err = ErrNoHealthyNode
+		return
+	}
+
+	for _, index := range indexes {
+		pool.mutex.RLock()
+		bitcoinRpc := pool.nodes[index].bitcoinRpc
+		pool.mutex.RUnlock()
+
+		err = fn(bitcoinRpc)
+		if ctx.Err() != nil {
+			return
+		}
+		if !isNodeFailure(err) {
+			if !errors.Is(err, context.Canceled) {
+				pool.markAnswered(index)
+			}
+			return
+		}
+		pool.markFailed(index, err)
+	}
+	return
+}
+
+// PinWallet routes the wallet calls of walletName to the node at nodeIndex.
+func (pool *NodePool) PinWallet(walletName string, nodeIndex int) (err error) {
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if nodeIndex < 0 || nodeIndex >= len(pool.nodes) {
+		err = fmt.Errorf("incorrect nodeIndex[%d]", nodeIndex)
+		return
+	}
+	pool.walletNodes[walletName] = nodeIndex
+	return
+}
+
+// WalletNode returns the node owning walletName: the pinned one, or else the
+// first node listing it in listwallets, which is then pinned.
+func (pool *NodePool) WalletNode(ctx context.Context, walletName string) (bitcoinRpc BitcoinRpc, err error) {
+
+	pool.mutex.RLock()
+	nodeIndex, pinned := pool.walletNodes[walletName]
+	if pinned {
+		bitcoinRpc = pool.nodes[nodeIndex].bitcoinRpc
+	}
+	pool.mutex.RUnlock()
+	if pinned {
+		return
+	}
+
+	for index := range pool.nodes {
+		pool.mutex.RLock()
+		nodeRpc := pool.nodes[index].bitcoinRpc
+		pool.mutex.RUnlock()
+
+		walletNames, errList := nodeRpc.ListWalletsCtx(ctx)
+		if errList != nil {
+			continue
+		}
+		for _, nodeWalletName := range walletNames {
+			if nodeWalletName == walletName {
+				err = pool.PinWallet(walletName, index)
+				bitcoinRpc = nodeRpc
+				return
+			}
+		}
+	}
+
+	err = fmt.Errorf("wallet[%s]: %w", walletName, ErrWalletNotFound)
+	return
+}
+
+func (pool *NodePool) GetBlockCountCtx(ctx context.Context) (blockCount int64, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		blockCount, errNode = bitcoinRpc.GetBlockCountCtx(ctx)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetBlockHashCtx(ctx context.Context, blockNumber int64) (blockHash string, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		blockHash, errNode = bitcoinRpc.GetBlockHashCtx(ctx, blockNumber)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetBlockCtx(ctx context.Context, blockHash string) (block Block, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		block, errNode = bitcoinRpc.GetBlockCtx(ctx, blockHash)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetBlockHexCtx(ctx context.Context, blockHash string) (blockHex string, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		blockHex, errNode = bitcoinRpc.GetBlockHexCtx(ctx, blockHash)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetBlockVerbosityCtx(ctx context.Context, blockHash string, verbosity int) (block BlockWithTransactions, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		block, errNode = bitcoinRpc.GetBlockVerbosityCtx(ctx, blockHash, verbosity)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetRawTransactionCtx(ctx context.Context, txID string) (rawTx RawTransaction, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		rawTx, errNode = bitcoinRpc.GetRawTransactionCtx(ctx, txID)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetBlockchainInfoCtx(ctx context.Context) (info BlockchainInfo, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		info, errNode = bitcoinRpc.GetBlockchainInfoCtx(ctx)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetChainTipsCtx(ctx context.Context) (tips []ChainTip, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		tips, errNode = bitcoinRpc.GetChainTipsCtx(ctx)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetRawMempoolCtx(ctx context.Context) (txIDs []string, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		txIDs, errNode = bitcoinRpc.GetRawMempoolCtx(ctx)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetMempoolEntryCtx(ctx context.Context, txID string) (entry MempoolEntry, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		entry, errNode = bitcoinRpc.GetMempoolEntryCtx(ctx, txID)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) TestMempoolAcceptCtx(ctx context.Context, signedRawTxs []string, maxFeeRate Amount) (results []MempoolAcceptResult, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		results, errNode = bitcoinRpc.TestMempoolAcceptCtx(ctx, signedRawTxs, maxFeeRate)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) EstimateSmartFeeCtx(ctx context.Context, confTarget int, mode string) (estimate SmartFeeEstimate, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		estimate, errNode = bitcoinRpc.EstimateSmartFeeCtx(ctx, confTarget, mode)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetBlockHeaderCtx(ctx context.Context, blockHash string) (header BlockHeader, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		header, errNode = bitcoinRpc.GetBlockHeaderCtx(ctx, blockHash)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetRawBlockHeaderCtx(ctx context.Context, blockHash string) (header RawBlockHeader, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		header, errNode = bitcoinRpc.GetRawBlockHeaderCtx(ctx, blockHash)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetRawTransactionVerbosityCtx(ctx context.Context, txID string, verbosity int, blockHash string) (rawTx RawTransaction, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		rawTx, errNode = bitcoinRpc.GetRawTransactionVerbosityCtx(ctx, txID, verbosity, blockHash)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) SendRawTransactionCtx(ctx context.Context, signedRawTx string) (txID string, err error) {
+	err = pool.Do(ctx, func(bitcoinRpc BitcoinRpc) (errNode error) {
+		txID, errNode = bitcoinRpc.SendRawTransactionWithRetryCtx(ctx, signedRawTx)
+		return
+	})
+	return
+}
+
+func (pool *NodePool) GetNewAddressCtx(ctx context.Context, walletName string, label string, addressType string) (newAddress string, err error) {
+
+	bitcoinRpc, err := pool.WalletNode(ctx, walletName)
+	if err != nil {
+		err = fmt.Errorf("@pool.WalletNode(ctx, walletName): %w", err)
+		return
+	}
+	return bitcoinRpc.GetNewAddressCtx(ctx, walletName, label, addressType)
+}
+
+func (pool *NodePool) ListReceivedByAddressCtx(ctx context.Context, walletName string, minconf int, includeEmpty bool, includeWatchonly bool, addressFilter string) (results []ReceivedByAddress, err error) {
+
+	bitcoinRpc, err := pool.WalletNode(ctx, walletName)
+	if err != nil {
+		err = fmt.Errorf("@pool.WalletNode(ctx, walletName): %w", err)
+		return
+	}
+	return bitcoinRpc.ListReceivedByAddressCtx(ctx, walletName, minconf, includeEmpty, includeWatchonly, addressFilter)
+}
+
+func (pool *NodePool) ListUnspentOfAddressCtx(ctx context.Context, walletName string, minconf int, maxconf int, addresses []string) (unspents []Unspent, err error) {
+
+	bitcoinRpc, err := pool.WalletNode(ctx, walletName)
+	if err != nil {
+		err = fmt.Errorf("@pool.WalletNode(ctx, walletName): %w", err)
+		return
+	}
+	bitcoinRpc.RpcPath = fmt.Sprintf("wallet/%s", walletName)
+	return bitcoinRpc.ListUnspentOfAddressCtx(ctx, minconf, maxconf, addresses)
+}
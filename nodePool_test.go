@@ -0,0 +1,168 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+func newPoolTestNode(t *testing.T, blockCount int64, initialBlockDownload bool, walletNames []string) (server *httptest.Server, bitcoinRpc BitcoinRpc) {
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jsonRpcInfo := make(map[string]interface{})
+		json.NewDecoder(r.Body).Decode(&jsonRpcInfo)
+		switch jsonRpcInfo["method"] {
+		case "getblockcount":
+			fmt.Fprintf(w, `{"result":%d,"error":null,"id":null}`, blockCount)
+		case "getblockchaininfo":
+			info := map[string]interface{}{"blocks": blockCount, "headers": blockCount + 10, "initialblockdownload": initialBlockDownload}
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": info, "error": nil, "id": nil})
+		case "listwallets":
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": walletNames, "error": nil, "id": nil})
+		case "getnewaddress":
+			fmt.Fprintf(w, `{"result":"%s-%d","error":null,"id":null}`, r.URL.Path, blockCount)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	serverURL, _ := url.Parse(server.URL)
+	bitcoinRpc = BitcoinRpc{
+		RpcUser:        "ideajoo",
+		RpcPW:          "ideajoo123",
+		RpcConnect:     serverURL.Hostname(),
+		RpcPort:        serverURL.Port(),
+		RpcRetryPolicy: &NoRetry,
+	}
+	return
+}
+
+func TestNodePool(t *testing.T) {
+
+	ctx := context.Background()
+	_, syncingNode := newPoolTestNode(t, 400, true, nil)
+	downServer, downNode := newPoolTestNode(t, 300, false, nil)
+	_, lowNode := newPoolTestNode(t, 100, false, []string{"test"})
+	_, highNode := newPoolTestNode(t, 200, false, nil)
+
+	pool, err := NewNodePool(syncingNode, downNode, lowNode, highNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool.CheckHealth(ctx)
+	// the node in initial block download is not preferred despite its blocks
+	blockCount, err := pool.GetBlockCountCtx(ctx)
+	if err != nil || blockCount != 300 {
+		t.Fatalf("expected the most synced node, got %d: %v", blockCount, err)
+	}
+	if status := pool.Status(); !status[0].Healthy || !status[0].InitialBlockDownload || status[0].Headers != 410 {
+		t.Fatalf("unexpected status of node 0 %+v", status[0])
+	}
+
+	// the most synced node goes down: fail over to the next one
+	downServer.Close()
+	blockCount, err = pool.GetBlockCountCtx(ctx)
+	if err != nil || blockCount != 200 {
+		t.Fatalf("expected a failover to the node at 200, got %d: %v", blockCount, err)
+	}
+	if status := pool.Status(); status[1].Healthy || status[1].LastError == nil {
+		t.Fatalf("expected node 1 to be unhealthy, got %+v", status[1])
+	}
+
+	// wallet calls stay on the node owning the wallet
+	newAddress, err := pool.GetNewAddressCtx(ctx, "test", "", "")
+	if err != nil || newAddress != "/wallet/test-100" {
+		t.Fatalf("expected the wallet node, got %s: %v", newAddress, err)
+	}
+	if _, err = pool.GetNewAddressCtx(ctx, "unknown", "", ""); err == nil {
+		t.Fatalf("expected an error for an unknown wallet")
+	}
+}
+
+func TestNodePoolLastResort(t *testing.T) {
+
+	ctx := context.Background()
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	pool, err := NewNodePool(newTestBitcoinRpc(server))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.SetError("getblockchaininfo", -28, "Loading block index...")
+	server.SetError("getblockcount", -28, "Loading block index...")
+	pool.CheckHealth(ctx)
+	if status := pool.Status(); status[0].Healthy {
+		t.Fatalf("expected node 0 to be unhealthy, got %+v", status[0])
+	}
+
+	// the node is still tried, and healthy again once it answers
+	server.SetResult("getblockcount", 100)
+	if _, err = pool.Best(); err != nil {
+		t.Fatalf("expected the unhealthy node, got %v", err)
+	}
+	blockCount, err := pool.GetBlockCountCtx(ctx)
+	if err != nil || blockCount != 100 {
+		t.Fatalf("expected the unhealthy node to be tried, got %d: %v", blockCount, err)
+	}
+	if status := pool.Status(); !status[0].Healthy || status[0].LastError != nil {
+		t.Fatalf("expected node 0 to be healthy again, got %+v", status[0])
+	}
+}
+
+func TestNodePoolTimeout(t *testing.T) {
+
+	hung := make(chan struct{})
+	hangingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-hung:
+		case <-r.Context().Done():
+		}
+	}))
+	defer hangingServer.Close()
+	defer close(hung)
+	serverURL, _ := url.Parse(hangingServer.URL)
+	hangingNode := BitcoinRpc{
+		RpcConnect:     serverURL.Hostname(),
+		RpcPort:        serverURL.Port(),
+		RpcTimeout:     20 * time.Millisecond,
+		RpcRetryPolicy: &NoRetry,
+	}
+	_, node := newPoolTestNode(t, 100, false, nil)
+
+	// the node hanging past its RpcTimeout is failed over
+	pool, err := NewNodePool(hangingNode, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockCount, err := pool.GetBlockCountCtx(context.Background())
+	if err != nil || blockCount != 100 {
+		t.Fatalf("expected a failover to the node at 100, got %d: %v", blockCount, err)
+	}
+	if status := pool.Status(); status[0].Healthy || !errors.Is(status[0].LastError, context.DeadlineExceeded) {
+		t.Fatalf("expected node 0 to be unhealthy, got %+v", status[0])
+	}
+
+	// the deadline of the caller is not the node's fault
+	hangingNode.RpcTimeout = 0
+	pool, err = NewNodePool(hangingNode, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = pool.GetBlockCountCtx(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if status := pool.Status(); !status[0].Healthy {
+		t.Fatalf("expected node 0 to stay healthy, got %+v", status[0])
+	}
+}
@@ -0,0 +1,242 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	defaultConfTarget       = 6
+	defaultMinConfirmations = 1
+)
+
+var (
+	ErrFeeRateTooHigh = errors.New("fee rate above the maximum")
+)
+
+// Payer pays from the unspents of a wallet whose keys dumpprivkey gives: it
+// selects the coins, adds the change to a new address, creates, signs and
+// broadcasts the transaction.
+type Payer struct {
+	BitcoinRpc        BitcoinRpc   // of the wallet, see WithWallet
+	WalletName        string       // for the getnewaddress of the change
+	Addresses         []string     // spend the unspents of these addresses only, all of the wallet when empty
+	MinConfirmations  int          // of the unspents, 1 when zero
+	FeeEstimator      FeeEstimator // a NodeFeeEstimator of BitcoinRpc when nil
+	ConfTarget        int          // 6 when zero
+	MaxFeeRate        FeeRate      // refuse to pay more, zero for no limit
+	ChangeAddressType string       // "legacy", "p2sh-segwit" or "bech32" (the default), as getnewaddress
+	ChangeLabel       string
+	MaxInputs         int  // zero for no limit
+	BIP69             bool // see CreateRawTransactionOptions
+	Replaceable       bool
+	DryRun            bool // sign but don't broadcast
+}
+
+type PaymentReceipt struct {
+	TxID          string
+	SignedRawTx   string
+	Inputs        []Unspent  // in the order of the transaction
+	Outputs       []TxOutput // in the order of the transaction, with the change
+	ChangeVout    int        // -1 without change
+	ChangeAddress string
+	Fee           Amount
+	FeeRate       FeeRate
+	VSize         int64 // of the signed transaction
+	Algorithm     string
+	Broadcast     bool // false for a dry run
+}
+
+func (payer Payer) changeType() (scriptType string, err error) {
+	switch payer.ChangeAddressType {
+	case "", "bech32":
+		return ScriptTypeP2WPKH, nil
+	case "p2sh-segwit":
+		return ScriptTypeP2SH, nil
+	case "legacy":
+		return ScriptTypeP2PKH, nil
+	}
+	err = fmt.Errorf("incorrect ChangeAddressType[%s]", payer.ChangeAddressType)
+	return
+}
+
+func (payer Payer) SendPayment(outputs []TxOutput) (receipt PaymentReceipt, err error) {
+	return payer.SendPaymentCtx(context.Background(), outputs)
+}
+
+// SendPaymentCtx pays outputs, which must not contain the change, at the fee
+// rate of FeeEstimator. It checks the transaction bitcoind creates and signs
+// against the selection before broadcasting it, unless DryRun.
+func (payer Payer) SendPaymentCtx(ctx context.Context, outputs []TxOutput) (receipt PaymentReceipt, err error) {
+
+	changeType, err := payer.changeType()
+	if err != nil {
+		return
+	}
+	confTarget := payer.ConfTarget
+	if confTarget == 0 {
+		confTarget = defaultConfTarget
+	}
+	minConfirmations := payer.MinConfirmations
+	if minConfirmations == 0 {
+		minConfirmations = defaultMinConfirmations
+	}
+	feeEstimator := payer.FeeEstimator
+	if feeEstimator == nil {
+		feeEstimator = NodeFeeEstimator{BitcoinRpc: payer.BitcoinRpc}
+	}
+
+	feeRate, err := feeEstimator.EstimateFeeRate(ctx, confTarget)
+	if err != nil {
+		err = fmt.Errorf("@feeEstimator.EstimateFeeRate(ctx, %d): %w", confTarget, err)
+		return
+	}
+	if payer.MaxFeeRate > 0 && feeRate > payer.MaxFeeRate {
+		err = fmt.Errorf("%w: %s > %s", ErrFeeRateTooHigh, feeRate, payer.MaxFeeRate)
+		return
+	}
+
+	addresses := payer.Addresses
+	if addresses == nil {
+		addresses = []string{}
+	}
+	unspents, err := payer.BitcoinRpc.ListUnspentOfAddressCtx(ctx, minConfirmations, 0, addresses)
+	if err != nil {
+		err = fmt.Errorf("@payer.BitcoinRpc.ListUnspentOfAddressCtx(ctx, %d, 0, addresses): %w", minConfirmations, err)
+		return
+	}
+	selector := CoinSelector{FeeRate: feeRate, ChangeType: changeType, MinConfirmations: minConfirmations, MaxInputs: payer.MaxInputs}
+	selection, err := selector.Select(unspents, outputs)
+	if err != nil {
+		err = fmt.Errorf("@selector.Select(unspents, outputs): %w", err)
+		return
+	}
+
+	txOutputs := append(make([]TxOutput, 0, len(outputs)+1), outputs...)
+	if selection.Change > 0 {
+		receipt.ChangeAddress, err = payer.BitcoinRpc.GetNewAddressCtx(ctx, payer.WalletName, payer.ChangeLabel, payer.ChangeAddressType)
+		if err != nil {
+			err = fmt.Errorf("@payer.BitcoinRpc.GetNewAddressCtx(ctx, %q, %q, %q): %w", payer.WalletName, payer.ChangeLabel, payer.ChangeAddressType, err)
+			return
+		}
+		txOutputs = append(txOutputs, TxOutput{Address: receipt.ChangeAddress, Amount: selection.Change, Change: true})
+	}
+
+	options := CreateRawTransactionOptions{BIP69: payer.BIP69, Replaceable: payer.Replaceable}
+	created, err := payer.BitcoinRpc.CreateRawTransactionOrderedCtx(ctx, selection.TxInputs(), txOutputs, options)
+	if err != nil {
+		err = fmt.Errorf("@payer.BitcoinRpc.CreateRawTransactionOrderedCtx(ctx, selection.TxInputs(), txOutputs, options): %w", err)
+		return
+	}
+	unsignedTx, err := created.Verify()
+	if err != nil {
+		err = fmt.Errorf("@created.Verify(): %w", err)
+		return
+	}
+
+	estimate, err := EstimateSignedSize(unsignedTx, selection.Inputs)
+	if err != nil {
+		err = fmt.Errorf("@EstimateSignedSize(unsignedTx, selection.Inputs): %v", err)
+		return
+	}
+	if estimate.Fee() != selection.Fee || estimate.Fee() < estimate.RequiredFee(feeRate) {
+		err = fmt.Errorf("fee %s of the transaction instead of %s at %s for %d vbytes", estimate.Fee(), selection.Fee, feeRate, estimate.VSize)
+		return
+	}
+
+	privKeys, err := payer.privateKeys(ctx, selection.Inputs)
+	if err != nil {
+		return
+	}
+	signed, err := payer.BitcoinRpc.SignRawTransactionWithKeysCtx(ctx, created.Hex, privKeys, SignRawTransactionOptions{})
+	if err != nil {
+		err = fmt.Errorf("@payer.BitcoinRpc.SignRawTransactionWithKeysCtx(ctx, created.Hex, privKeys, SignRawTransactionOptions{}): %w", err)
+		return
+	}
+	signedRawTx := signed.Hex
+	signedTx, err := verifySigned(signedRawTx, unsignedTx)
+	if err != nil {
+		return
+	}
+
+	receipt.TxID = signedTx.TxID()
+	receipt.SignedRawTx = signedRawTx
+	for _, txInput := range created.Inputs {
+		for _, unspent := range selection.Inputs {
+			if unspent.TxID == txInput.TxID && unspent.Vout == txInput.Vout {
+				receipt.Inputs = append(receipt.Inputs, unspent)
+			}
+		}
+	}
+	receipt.Outputs = created.Outputs
+	receipt.ChangeVout = created.ChangeVout
+	receipt.Fee = selection.Fee
+	receipt.VSize = int64(signedTx.VSize())
+	receipt.FeeRate = FeeRateOf(receipt.Fee, receipt.VSize)
+	receipt.Algorithm = selection.Algorithm
+	if payer.MaxFeeRate > 0 && receipt.FeeRate > payer.MaxFeeRate {
+		err = fmt.Errorf("%w: %s > %s once signed", ErrFeeRateTooHigh, receipt.FeeRate, payer.MaxFeeRate)
+		return
+	}
+	if payer.DryRun {
+		return
+	}
+
+	txID, err := payer.BitcoinRpc.SendRawTransactionWithRetryCtx(ctx, signedRawTx)
+	if err != nil {
+		err = fmt.Errorf("@payer.BitcoinRpc.SendRawTransactionWithRetryCtx(ctx, signedRawTx): %w", err)
+		return
+	}
+	if txID != receipt.TxID {
+		err = fmt.Errorf("broadcast txid %s instead of %s", txID, receipt.TxID)
+		return
+	}
+	receipt.Broadcast = true
+	return
+}
+
+// privateKeys dumps the keys of the addresses of unspents, once each.
+func (payer Payer) privateKeys(ctx context.Context, unspents []Unspent) (privKeys []string, err error) {
+
+	addresses := []string{}
+	seen := make(map[string]bool)
+	for _, unspent := range unspents {
+		if !seen[unspent.Address] {
+			seen[unspent.Address] = true
+			addresses = append(addresses, unspent.Address)
+		}
+	}
+	sort.Strings(addresses)
+
+	for _, address := range addresses {
+		privKey, errKey := payer.BitcoinRpc.DumpPrivateKeyCtx(ctx, address)
+		if errKey != nil {
+			err = fmt.Errorf("@payer.BitcoinRpc.DumpPrivateKeyCtx(ctx, %s): %w", address, errKey)
+			return
+		}
+		privKeys = append(privKeys, privKey)
+	}
+	return
+}
+
+// verifySigned checks that signing only added the scriptSigs and witnesses.
+func verifySigned(signedRawTx string, unsignedTx Transaction) (signedTx Transaction, err error) {
+
+	signedTx, err = DecodeTransactionHex(signedRawTx)
+	if err != nil {
+		err = fmt.Errorf("@DecodeTransactionHex(signedRawTx): %v", err)
+		return
+	}
+	stripped := signedTx
+	stripped.Inputs = make([]TransactionInput, len(signedTx.Inputs))
+	for index, input := range signedTx.Inputs {
+		stripped.Inputs[index] = TransactionInput{PrevTxID: input.PrevTxID, PrevVout: input.PrevVout, Sequence: input.Sequence}
+	}
+	if stripped.Hex() != unsignedTx.Hex() {
+		err = fmt.Errorf("%w: the signed transaction differs from the created one", ErrTransactionMismatch)
+		return
+	}
+	return
+}
@@ -0,0 +1,178 @@
+package gobitcoinclilight
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+// handlePayment makes server create, sign and broadcast transactions like
+// bitcoind: createrawtransaction builds the transaction of its params, and
+// signrawtransactionwithkey adds P2WPKH witnesses of the maximal size, or
+// leaves them out when complete is false.
+func handlePayment(server *bitcoindtest.Server, complete bool) {
+
+	server.Handle("createrawtransaction", func(request bitcoindtest.Request) (interface{}, *bitcoindtest.Error) {
+		inputs, outputs, replaceable := []TxInput{}, []map[string]json.RawMessage{}, false
+		if request.Param(0, &inputs) != nil || request.Param(1, &outputs) != nil {
+			return nil, &bitcoindtest.Error{Code: -8, Message: "incorrect params"}
+		}
+		if len(request.Params) > 3 {
+			request.Param(3, &replaceable)
+		}
+		tx := Transaction{Version: 2}
+		for _, txInput := range inputs {
+			prevTxID, err := NewHashFromString(txInput.TxID)
+			if err != nil {
+				return nil, &bitcoindtest.Error{Code: -8, Message: err.Error()}
+			}
+			sequence := uint32(0xffffffff)
+			if replaceable {
+				sequence = SequenceRBF
+			}
+			tx.Inputs = append(tx.Inputs, TransactionInput{PrevTxID: prevTxID, PrevVout: uint32(txInput.Vout), Sequence: sequence})
+		}
+		for _, output := range outputs {
+			for key, value := range output {
+				txOutput := TxOutput{}
+				if key == "data" {
+					json.Unmarshal(value, &txOutput.Data)
+				} else {
+					txOutput.Address = key
+					json.Unmarshal(value, &txOutput.Amount)
+				}
+				scriptPubKey, err := txOutput.ScriptPubKey()
+				if err != nil {
+					return nil, &bitcoindtest.Error{Code: -5, Message: err.Error()}
+				}
+				tx.Outputs = append(tx.Outputs, TransactionOutput{Value: txOutput.Amount, ScriptPubKey: scriptPubKey})
+			}
+		}
+		return tx.Hex(), nil
+	})
+
+	server.Handle("signrawtransactionwithkey", func(request bitcoindtest.Request) (interface{}, *bitcoindtest.Error) {
+		rawTx := ""
+		request.Param(0, &rawTx)
+		tx, err := DecodeTransactionHex(rawTx)
+		if err != nil {
+			return nil, &bitcoindtest.Error{Code: -22, Message: err.Error()}
+		}
+		if complete {
+			for index := range tx.Inputs {
+				tx.Inputs[index].Witness = [][]byte{make([]byte, ecdsaSignatureSize), make([]byte, 33)}
+			}
+		}
+		return map[string]interface{}{"hex": tx.Hex(), "complete": complete}, nil
+	})
+
+	server.Handle("sendrawtransaction", func(request bitcoindtest.Request) (interface{}, *bitcoindtest.Error) {
+		signedRawTx := ""
+		request.Param(0, &signedRawTx)
+		tx, err := DecodeTransactionHex(signedRawTx)
+		if err != nil {
+			return nil, &bitcoindtest.Error{Code: -22, Message: err.Error()}
+		}
+		return tx.TxID(), nil
+	})
+}
+
+func TestPayer(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	handlePayment(server, true)
+	server.SetResult("listunspent", p2wpkhUnspents(50000, 30000, 20000))
+
+	payer := Payer{
+		BitcoinRpc:   newTestBitcoinRpc(server),
+		WalletName:   "test",
+		FeeEstimator: StaticFeeEstimator{FeeRate: 1000},
+		DryRun:       true,
+	}
+	payment := []TxOutput{{Address: "tb1qmhqe8pr06v0mefelardj4h6hkq095e5dh72mv3", Amount: 60000}}
+
+	receipt, err := payer.SendPayment(payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Broadcast || len(server.RequestsFor("sendrawtransaction")) != 0 {
+		t.Fatalf("a dry run must not broadcast")
+	}
+	if receipt.Fee != 209 || receipt.VSize != 209 || receipt.FeeRate != 1000 || len(receipt.Inputs) != 2 || receipt.Algorithm != CoinSelectionKnapsack {
+		t.Fatalf("unexpected receipt %+v", receipt)
+	}
+	if receipt.ChangeVout != 1 || receipt.ChangeAddress != "tb1qa6v5vvpagj7lqnummqff0jm086y3vq3jjc9r90" || receipt.Outputs[1].Amount != 9791 || !receipt.Outputs[1].Change {
+		t.Fatalf("unexpected change of receipt %+v", receipt)
+	}
+	signedTx, err := DecodeTransactionHex(receipt.SignedRawTx)
+	if err != nil || signedTx.TxID() != receipt.TxID {
+		t.Fatalf("unexpected signed transaction %s: %v", receipt.SignedRawTx, err)
+	}
+	minConfirmations := 0
+	if err = server.RequestsFor("listunspent")[0].Param(0, &minConfirmations); err != nil || minConfirmations != 1 {
+		t.Fatalf("expected unspents of 1 confirmation at least, got %d: %v", minConfirmations, err)
+	}
+	replaceable := true
+	if err = server.RequestsFor("createrawtransaction")[0].Param(3, &replaceable); err != nil || replaceable {
+		t.Fatalf("expected replaceable false to be sent: %v", err)
+	}
+	privKeys := []string{}
+	if err = server.RequestsFor("signrawtransactionwithkey")[0].Param(1, &privKeys); err != nil || len(privKeys) != 1 || privKeys[0] != bitcoindtest.FixturePrivKey {
+		t.Fatalf("unexpected private keys %v: %v", privKeys, err)
+	}
+
+	payer.DryRun = false
+	broadcast, err := payer.SendPayment(payment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !broadcast.Broadcast || broadcast.TxID != receipt.TxID || len(server.RequestsFor("sendrawtransaction")) != 1 {
+		t.Fatalf("unexpected broadcast receipt %+v", broadcast)
+	}
+
+	// without change, no new address
+	addresses := len(server.RequestsFor("getnewaddress"))
+	server.SetResult("listunspent", p2wpkhUnspents(50000, 10120, 30000))
+	receipt, err = payer.SendPayment([]TxOutput{{Address: "tb1qmhqe8pr06v0mefelardj4h6hkq095e5dh72mv3", Amount: 10000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.ChangeVout != -1 || receipt.Fee != 120 || len(receipt.Outputs) != 1 || len(server.RequestsFor("getnewaddress")) != addresses {
+		t.Fatalf("unexpected receipt without change %+v", receipt)
+	}
+
+	if _, err = payer.SendPayment([]TxOutput{{Address: "tb1qmhqe8pr06v0mefelardj4h6hkq095e5dh72mv3", Amount: 100000}}); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected ErrInsufficientFunds, got %v", err)
+	}
+
+	payer.MaxFeeRate = 500
+	if _, err = payer.SendPayment(payment); !errors.Is(err, ErrFeeRateTooHigh) {
+		t.Fatalf("expected ErrFeeRateTooHigh, got %v", err)
+	}
+	payer.MaxFeeRate = 0
+
+	payer.ChangeAddressType = "p2tr"
+	if _, err = payer.SendPayment(payment); err == nil {
+		t.Fatalf("expected an error for ChangeAddressType %s", payer.ChangeAddressType)
+	}
+}
+
+func TestPayerIncompleteSignature(t *testing.T) {
+
+	server := bitcoindtest.NewServerWithFixtures()
+	defer server.Close()
+	handlePayment(server, false)
+	server.SetResult("listunspent", p2wpkhUnspents(50000, 30000, 20000))
+
+	payer := Payer{BitcoinRpc: newTestBitcoinRpc(server), FeeEstimator: StaticFeeEstimator{FeeRate: 1000}}
+	_, err := payer.SendPayment([]TxOutput{{Address: "tb1qmhqe8pr06v0mefelardj4h6hkq095e5dh72mv3", Amount: 60000}})
+	if !errors.Is(err, ErrIncompleteSignature) {
+		t.Fatalf("expected ErrIncompleteSignature, got %v", err)
+	}
+	if len(server.RequestsFor("sendrawtransaction")) != 0 {
+		t.Fatalf("an incomplete transaction must not be broadcast")
+	}
+}
@@ -0,0 +1,185 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy retries an idempotent call with exponential backoff and jitter.
+type RetryPolicy struct {
+	MaxAttempts    int                  // total attempts including the first one, <= 1 disables retries
+	InitialBackoff time.Duration        // wait before the second attempt
+	MaxBackoff     time.Duration        // upper bound of a single wait
+	Multiplier     float64              // backoff growth per attempt
+	Jitter         float64              // 0..1, fraction of each wait which is randomized
+	Retryable      func(err error) bool // nil means IsRetryable
+}
+
+// DefaultRetryPolicy is used by the read-only calls of a BitcoinRpc without RpcRetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 250 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// NoRetry disables retries, e.g. BitcoinRpc{..., RpcRetryPolicy: &NoRetry}.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// IsRetryable reports whether err is transient: bitcoind warming up (-28),
+// an overloaded work queue (503), a gateway error, a dropped connection or a
+// timeout, e.g. of RpcTimeout. A refused connection is not retried, the node is
+// down rather than busy. The deadline of the caller's ctx is told apart by
+// RetryPolicy.Do, which stops once ctx is done.
+func IsRetryable(err error) bool {
+
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if errors.Is(err, ErrInWarmup) {
+		return true
+	}
+
+	httpError := &HTTPError{}
+	if errors.As(err, &httpError) {
+		switch httpError.StatusCode {
+		case http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	rpcError := &RPCError{}
+	if errors.As(err, &rpcError) {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true
+	}
+	return false
+}
+
+func (retryPolicy RetryPolicy) backoff(attempt int) (wait time.Duration) {
+
+	multiplier := retryPolicy.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	wait = time.Duration(float64(retryPolicy.InitialBackoff) * math.Pow(multiplier, float64(attempt-1)))
+	if retryPolicy.MaxBackoff > 0 && wait > retryPolicy.MaxBackoff {
+		wait = retryPolicy.MaxBackoff
+	}
+	if retryPolicy.Jitter > 0 {
+		jitter := math.Min(retryPolicy.Jitter, 1)
+		wait = time.Duration(float64(wait) * (1 - jitter + 2*jitter*rand.Float64()))
+	}
+	return
+}
+
+// Do calls fn until it succeeds, returns a non retryable error, MaxAttempts is
+// reached or ctx is done. fn should use ctx, so that an error of ctx itself ends
+// the retries while the timeouts of single attempts don't.
+func (retryPolicy RetryPolicy) Do(ctx context.Context, fn func() error) (err error) {
+
+	retryable := retryPolicy.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || ctx.Err() != nil || attempt >= retryPolicy.MaxAttempts || !retryable(err) {
+			return
+		}
+
+		timer := time.NewTimer(retryPolicy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			err = fmt.Errorf("%w (after %d attempts, last error: %v)", ctx.Err(), attempt, err)
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (bitcoinRpc BitcoinRpc) retryPolicy() RetryPolicy {
+	if bitcoinRpc.RpcRetryPolicy != nil {
+		return *bitcoinRpc.RpcRetryPolicy
+	}
+	return DefaultRetryPolicy
+}
+
+// requestIdempotent is request with the retry policy, for calls which are safe to repeat.
+func (bitcoinRpc BitcoinRpc) requestIdempotent(ctx context.Context, jsonRpcBytes []byte) (body []byte, err error) {
+
+	err = bitcoinRpc.retryPolicy().Do(ctx, func() (errAttempt error) {
+		body, errAttempt = bitcoinRpc.request(ctx, jsonRpcBytes)
+		return
+	})
+	return
+}
+
+func WithRetryPolicy(retryPolicy RetryPolicy) ClientOption {
+	return func(config *clientConfig) (err error) {
+		config.bitcoinRpc.RpcRetryPolicy = &retryPolicy
+		return
+	}
+}
+
+func (bitcoinRpc BitcoinRpc) SendRawTransactionWithRetry(signedRawTx string) (txID string, err error) {
+	return bitcoinRpc.SendRawTransactionWithRetryCtx(context.Background(), signedRawTx)
+}
+
+// SendRawTransactionWithRetryCtx broadcasts with the retry policy. Broadcasting
+// again is harmless, so a transaction which bitcoind reports as already in the
+// chain or in the mempool (e.g. an earlier attempt went through before the
+// connection dropped) counts as success and its txid is returned.
+func (bitcoinRpc BitcoinRpc) SendRawTransactionWithRetryCtx(ctx context.Context, signedRawTx string) (txID string, err error) {
+
+	err = bitcoinRpc.retryPolicy().Do(ctx, func() (errAttempt error) {
+		txID, errAttempt = bitcoinRpc.SendRawTransactionCtx(ctx, signedRawTx)
+		return
+	})
+	if err == nil || !isAlreadyBroadcast(err) {
+		return
+	}
+
+	decodedTx, err := bitcoinRpc.DecodeRawTransactionCtx(ctx, signedRawTx)
+	if err != nil {
+		err = fmt.Errorf("@bitcoinRpc.DecodeRawTransactionCtx(ctx, signedRawTx): %w", err)
+		return
+	}
+
+	txID = decodedTx.TxID
+	return
+}
+
+func isAlreadyBroadcast(err error) bool {
+
+	if errors.Is(err, ErrVerifyAlreadyInChain) {
+		return true
+	}
+	rpcError := &RPCError{}
+	if errors.As(err, &rpcError) && rpcError.Code == ErrVerifyRejected.Code {
+		return strings.Contains(rpcError.Message, "txn-already-in-mempool") || strings.Contains(rpcError.Message, "txn-already-known")
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package gobitcoinclilight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy(t *testing.T) {
+
+	var attempts int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jsonRpcInfo := make(map[string]interface{})
+		json.NewDecoder(r.Body).Decode(&jsonRpcInfo)
+		switch jsonRpcInfo["method"] {
+		case "getblockcount":
+			if atomic.AddInt64(&attempts, 1) < 3 {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"result":null,"error":{"code":-28,"message":"Loading block index..."},"id":null}`)
+				return
+			}
+			fmt.Fprint(w, `{"result":2344981,"error":null,"id":null}`)
+		case "sendrawtransaction":
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"result":null,"error":{"code":-27,"message":"Transaction already in block chain"},"id":null}`)
+		case "decoderawtransaction":
+			fmt.Fprint(w, `{"result":{"txid":"fb92e4a2aab9e55f11dfe3bf047a8d37fde0b274e99cee08db943f12f6975788"},"error":null,"id":null}`)
+		}
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	retryPolicy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2, Jitter: 0.5}
+	bitcoinRpc := BitcoinRpc{
+		RpcUser:        "ideajoo",
+		RpcPW:          "ideajoo123",
+		RpcConnect:     serverURL.Hostname(),
+		RpcPort:        serverURL.Port(),
+		RpcRetryPolicy: &retryPolicy,
+	}
+
+	blockCount, err := bitcoinRpc.GetBlockCount()
+	if err != nil || blockCount != 2344981 || attempts != 3 {
+		t.Fatalf("unexpected blockCount %d after %d attempts: %v", blockCount, attempts, err)
+	}
+
+	atomic.StoreInt64(&attempts, 0)
+	bitcoinRpc.RpcRetryPolicy = &NoRetry
+	if _, err = bitcoinRpc.GetBlockCount(); !errors.Is(err, ErrInWarmup) || attempts != 1 {
+		t.Fatalf("expected a single attempt failing with ErrInWarmup, got %d attempts: %v", attempts, err)
+	}
+
+	// not retried, and no special casing of "already in chain" without opting in
+	if _, err = bitcoinRpc.SendRawTransaction("0200"); !errors.Is(err, ErrVerifyAlreadyInChain) {
+		t.Fatalf("expected ErrVerifyAlreadyInChain, got %v", err)
+	}
+	txID, err := bitcoinRpc.SendRawTransactionWithRetry("0200")
+	if err != nil || txID != "fb92e4a2aab9e55f11dfe3bf047a8d37fde0b274e99cee08db943f12f6975788" {
+		t.Fatalf("unexpected txID %s: %v", txID, err)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+
+	retryables := []error{
+		&RPCError{Code: -28, Message: "Verifying blocks..."},
+		fmt.Errorf("@bitcoinRpc.request(ctx, jsonRpcBytes): %w", &HTTPError{StatusCode: http.StatusServiceUnavailable}),
+		fmt.Errorf("@bitcoinRpc.httpClient.Do(request): %w", context.DeadlineExceeded),
+	}
+	for _, err := range retryables {
+		if !IsRetryable(err) {
+			t.Errorf("expected %v to be retryable", err)
+		}
+	}
+	notRetryables := []error{
+		ErrInvalidAddressOrKey,
+		context.Canceled,
+		&HTTPError{StatusCode: http.StatusUnauthorized},
+		errors.New("unknown"),
+	}
+	for _, err := range notRetryables {
+		if IsRetryable(err) {
+			t.Errorf("expected %v not to be retryable", err)
+		}
+	}
+}
+
+func TestRetryPolicyTimeout(t *testing.T) {
+
+	var attempts int64
+	hung := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&attempts, 1) < 3 {
+			// hangs past RpcTimeout
+			select {
+			case <-hung:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		fmt.Fprint(w, `{"result":2344981,"error":null,"id":null}`)
+	}))
+	defer server.Close()
+	defer close(hung)
+	serverURL, _ := url.Parse(server.URL)
+
+	retryPolicy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	bitcoinRpc := BitcoinRpc{
+		RpcUser:        "ideajoo",
+		RpcPW:          "ideajoo123",
+		RpcConnect:     serverURL.Hostname(),
+		RpcPort:        serverURL.Port(),
+		RpcTimeout:     20 * time.Millisecond,
+		RpcRetryPolicy: &retryPolicy,
+	}
+
+	// the timeouts of the attempts are retried
+	blockCount, err := bitcoinRpc.GetBlockCount()
+	if err != nil || blockCount != 2344981 || atomic.LoadInt64(&attempts) != 3 {
+		t.Fatalf("unexpected blockCount %d after %d attempts: %v", blockCount, attempts, err)
+	}
+
+	// the deadline of the caller is not
+	atomic.StoreInt64(&attempts, 0)
+	bitcoinRpc.RpcTimeout = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = bitcoinRpc.GetBlockCountCtx(ctx); !errors.Is(err, context.DeadlineExceeded) || atomic.LoadInt64(&attempts) != 1 {
+		t.Fatalf("expected a single attempt failing with context.DeadlineExceeded, got %d attempts: %v", attempts, err)
+	}
+}
@@ -0,0 +1,112 @@
+package gobitcoinclilight
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RPCError is the "error" object of a bitcoind JSON-RPC response.
+// Compare against the Err* values below with errors.Is, or read Code/Message with errors.As.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (rpcError *RPCError) Error() string {
+	return fmt.Sprintf("bitcoind rpc error %d: %s", rpcError.Code, rpcError.Message)
+}
+
+// Is reports whether target is an *RPCError with the same Code, so
+// errors.Is(err, ErrInvalidAddressOrKey) matches whatever message bitcoind sent.
+func (rpcError *RPCError) Is(target error) bool {
+	targetRpcError, ok := target.(*RPCError)
+	if !ok {
+		return false
+	}
+	return rpcError.Code == targetRpcError.Code
+}
+
+// bitcoind error codes (src/rpc/protocol.h)
+var (
+	// Standard JSON-RPC 2.0 errors
+	ErrInvalidRequest = &RPCError{Code: -32600, Message: "RPC_INVALID_REQUEST"}
+	ErrMethodNotFound = &RPCError{Code: -32601, Message: "RPC_METHOD_NOT_FOUND"}
+	ErrInvalidParams  = &RPCError{Code: -32602, Message: "RPC_INVALID_PARAMS"}
+	ErrInternalError  = &RPCError{Code: -32603, Message: "RPC_INTERNAL_ERROR"}
+	ErrParseError     = &RPCError{Code: -32700, Message: "RPC_PARSE_ERROR"}
+
+	// General application defined errors
+	ErrMiscError            = &RPCError{Code: -1, Message: "RPC_MISC_ERROR"}
+	ErrTypeError            = &RPCError{Code: -3, Message: "RPC_TYPE_ERROR"}
+	ErrInvalidAddressOrKey  = &RPCError{Code: -5, Message: "RPC_INVALID_ADDRESS_OR_KEY"}
+	ErrOutOfMemory          = &RPCError{Code: -7, Message: "RPC_OUT_OF_MEMORY"}
+	ErrInvalidParameter     = &RPCError{Code: -8, Message: "RPC_INVALID_PARAMETER"}
+	ErrDatabaseError        = &RPCError{Code: -20, Message: "RPC_DATABASE_ERROR"}
+	ErrDeserializationError = &RPCError{Code: -22, Message: "RPC_DESERIALIZATION_ERROR"}
+	ErrVerifyError          = &RPCError{Code: -25, Message: "RPC_VERIFY_ERROR"}
+	ErrVerifyRejected       = &RPCError{Code: -26, Message: "RPC_VERIFY_REJECTED"}
+	ErrVerifyAlreadyInChain = &RPCError{Code: -27, Message: "RPC_VERIFY_ALREADY_IN_CHAIN"}
+	ErrInWarmup             = &RPCError{Code: -28, Message: "RPC_IN_WARMUP"}
+	ErrMethodDeprecated     = &RPCError{Code: -32, Message: "RPC_METHOD_DEPRECATED"}
+
+	// P2P client errors
+	ErrClientNotConnected        = &RPCError{Code: -9, Message: "RPC_CLIENT_NOT_CONNECTED"}
+	ErrClientInInitialDownload   = &RPCError{Code: -10, Message: "RPC_CLIENT_IN_INITIAL_DOWNLOAD"}
+	ErrClientNodeAlreadyAdded    = &RPCError{Code: -23, Message: "RPC_CLIENT_NODE_ALREADY_ADDED"}
+	ErrClientNodeNotAdded        = &RPCError{Code: -24, Message: "RPC_CLIENT_NODE_NOT_ADDED"}
+	ErrClientNodeNotConnected    = &RPCError{Code: -29, Message: "RPC_CLIENT_NODE_NOT_CONNECTED"}
+	ErrClientInvalidIPOrSubnet   = &RPCError{Code: -30, Message: "RPC_CLIENT_INVALID_IP_OR_SUBNET"}
+	ErrClientP2PDisabled         = &RPCError{Code: -31, Message: "RPC_CLIENT_P2P_DISABLED"}
+	ErrClientMempoolDisabled     = &RPCError{Code: -33, Message: "RPC_CLIENT_MEMPOOL_DISABLED"}
+	ErrClientNodeCapacityReached = &RPCError{Code: -34, Message: "RPC_CLIENT_NODE_CAPACITY_REACHED"}
+
+	// Wallet errors
+	ErrWalletError               = &RPCError{Code: -4, Message: "RPC_WALLET_ERROR"}
+	ErrWalletInsufficientFunds   = &RPCError{Code: -6, Message: "RPC_WALLET_INSUFFICIENT_FUNDS"}
+	ErrWalletInvalidLabelName    = &RPCError{Code: -11, Message: "RPC_WALLET_INVALID_LABEL_NAME"}
+	ErrWalletKeypoolRanOut       = &RPCError{Code: -12, Message: "RPC_WALLET_KEYPOOL_RAN_OUT"}
+	ErrWalletUnlockNeeded        = &RPCError{Code: -13, Message: "RPC_WALLET_UNLOCK_NEEDED"}
+	ErrWalletPassphraseIncorrect = &RPCError{Code: -14, Message: "RPC_WALLET_PASSPHRASE_INCORRECT"}
+	ErrWalletWrongEncState       = &RPCError{Code: -15, Message: "RPC_WALLET_WRONG_ENC_STATE"}
+	ErrWalletEncryptionFailed    = &RPCError{Code: -16, Message: "RPC_WALLET_ENCRYPTION_FAILED"}
+	ErrWalletAlreadyUnlocked     = &RPCError{Code: -17, Message: "RPC_WALLET_ALREADY_UNLOCKED"}
+	ErrWalletNotFound            = &RPCError{Code: -18, Message: "RPC_WALLET_NOT_FOUND"}
+	ErrWalletNotSpecified        = &RPCError{Code: -19, Message: "RPC_WALLET_NOT_SPECIFIED"}
+	ErrWalletAlreadyLoaded       = &RPCError{Code: -35, Message: "RPC_WALLET_ALREADY_LOADED"}
+	ErrWalletAlreadyExists       = &RPCError{Code: -36, Message: "RPC_WALLET_ALREADY_EXISTS"}
+)
+
+// HTTPError is returned when bitcoind answers with a non-200 status and no
+// JSON-RPC error object, e.g. 401 for wrong credentials or 403 for rpcwhitelist.
+type HTTPError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (httpError *HTTPError) Error() string {
+	status := httpError.Status
+	if status == "" {
+		status = fmt.Sprintf("%d %s", httpError.StatusCode, http.StatusText(httpError.StatusCode))
+	}
+	if httpError.Body == "" {
+		return fmt.Sprintf("bitcoind http error: %s", status)
+	}
+	return fmt.Sprintf("bitcoind http error: %s: %s", status, httpError.Body)
+}
+
+// Is reports whether target is an *HTTPError with the same StatusCode.
+func (httpError *HTTPError) Is(target error) bool {
+	targetHttpError, ok := target.(*HTTPError)
+	if !ok {
+		return false
+	}
+	return httpError.StatusCode == targetHttpError.StatusCode
+}
+
+var (
+	ErrUnauthorized        = &HTTPError{StatusCode: http.StatusUnauthorized}
+	ErrForbidden           = &HTTPError{StatusCode: http.StatusForbidden}
+	ErrInternalServerError = &HTTPError{StatusCode: http.StatusInternalServerError}
+	ErrServiceUnavailable  = &HTTPError{StatusCode: http.StatusServiceUnavailable}
+)
@@ -0,0 +1,401 @@
+package gobitcoinclilight
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Hash is a double-SHA256 in internal byte order; String gives the reversed
+// hex which bitcoind displays for txids and block hashes.
+type Hash [32]byte
+
+func NewHashFromString(hexHash string) (hash Hash, err error) {
+
+	decoded, err := hex.DecodeString(hexHash)
+	if err != nil {
+		err = fmt.Errorf("@hex.DecodeString(hexHash): %v", err)
+		return
+	}
+	if len(decoded) != len(hash) {
+		err = fmt.Errorf("incorrect hash length %d", len(decoded))
+		return
+	}
+	for i, b := range decoded {
+		hash[len(hash)-1-i] = b
+	}
+	return
+}
+
+func (hash Hash) String() string {
+	reversed := make([]byte, len(hash))
+	for i, b := range hash {
+		reversed[len(hash)-1-i] = b
+	}
+	return hex.EncodeToString(reversed)
+}
+
+func hashOf(data []byte) (hash Hash) {
+	copy(hash[:], doubleSha256(data))
+	return
+}
+
+// Transaction is a bitcoin transaction as serialized on the wire, with the
+// witnesses of BIP144 when any input has one.
+type Transaction struct {
+	Version  int32
+	Inputs   []TransactionInput
+	Outputs  []TransactionOutput
+	LockTime uint32
+}
+
+type TransactionInput struct {
+	PrevTxID  Hash
+	PrevVout  uint32
+	ScriptSig []byte
+	Sequence  uint32
+	Witness   [][]byte
+}
+
+type TransactionOutput struct {
+	Value        Amount
+	ScriptPubKey []byte
+}
+
+const witnessScaleFactor = 4
+
+// DecodeTransactionHex decodes the hex strings bitcoind sends and receives.
+func DecodeTransactionHex(rawTx string) (tx Transaction, err error) {
+
+	data, err := hex.DecodeString(rawTx)
+	if err != nil {
+		err = fmt.Errorf("@hex.DecodeString(rawTx): %v", err)
+		return
+	}
+	return DecodeTransaction(data)
+}
+
+// DecodeTransaction decodes a legacy or segwit transaction. Like bitcoind, it
+// falls back to the legacy format for an input-less transaction whose output
+// count looks like the segwit flag.
+func DecodeTransaction(data []byte) (tx Transaction, err error) {
+
+	tx, err = decodeTransaction(data, true)
+	if err == nil {
+		return
+	}
+	legacyTx, errLegacy := decodeTransaction(data, false)
+	if errLegacy == nil {
+		tx, err = legacyTx, nil
+	}
+	return
+}
+
+func decodeTransaction(data []byte, allowWitness bool) (tx Transaction, err error) {
+
+	reader := bytes.NewReader(data)
+	tx, err = readTransaction(reader, allowWitness)
+	if err != nil {
+		return
+	}
+	if reader.Len() != 0 {
+		err = fmt.Errorf("%d trailing bytes after the transaction", reader.Len())
+	}
+	return
+}
+
+func readTransaction(reader *bytes.Reader, allowWitness bool) (tx Transaction, err error) {
+
+	version, err := readUint32(reader)
+	if err != nil {
+		return
+	}
+	tx.Version = int32(version)
+
+	inputCount, err := readCompactSize(reader)
+	if err != nil {
+		return
+	}
+	segwit := false
+	if inputCount == 0 && allowWitness {
+		flag, errFlag := reader.ReadByte()
+		if errFlag != nil {
+			err = fmt.Errorf("segwit flag: %w", io.ErrUnexpectedEOF)
+			return
+		}
+		if flag != 1 {
+			err = fmt.Errorf("unknown segwit flag %d", flag)
+			return
+		}
+		segwit = true
+		inputCount, err = readCompactSize(reader)
+		if err != nil {
+			return
+		}
+	}
+
+	// an input takes at least 41 bytes, an output 9: reject counts the data can't hold
+	if inputCount > uint64(reader.Len())/41 {
+		err = fmt.Errorf("input count %d exceeds the data", inputCount)
+		return
+	}
+	tx.Inputs = make([]TransactionInput, inputCount)
+	for i := range tx.Inputs {
+		input := &tx.Inputs[i]
+		if _, err = io.ReadFull(reader, input.PrevTxID[:]); err != nil {
+			err = fmt.Errorf("inputs[%d]: %w", i, io.ErrUnexpectedEOF)
+			return
+		}
+		if input.PrevVout, err = readUint32(reader); err != nil {
+			return
+		}
+		if input.ScriptSig, err = readVarBytes(reader); err != nil {
+			err = fmt.Errorf("inputs[%d] scriptSig: %w", i, err)
+			return
+		}
+		if input.Sequence, err = readUint32(reader); err != nil {
+			return
+		}
+	}
+
+	outputCount, err := readCompactSize(reader)
+	if err != nil {
+		return
+	}
+	if outputCount > uint64(reader.Len())/9 {
+		err = fmt.Errorf("output count %d exceeds the data", outputCount)
+		return
+	}
+	tx.Outputs = make([]TransactionOutput, outputCount)
+	for i := range tx.Outputs {
+		output := &tx.Outputs[i]
+		value := uint64(0)
+		if err = binary.Read(reader, binary.LittleEndian, &value); err != nil {
+			err = fmt.Errorf("outputs[%d] value: %w", i, io.ErrUnexpectedEOF)
+			return
+		}
+		output.Value = Amount(value)
+		if output.ScriptPubKey, err = readVarBytes(reader); err != nil {
+			err = fmt.Errorf("outputs[%d] scriptPubKey: %w", i, err)
+			return
+		}
+	}
+
+	if segwit {
+		if len(tx.Inputs) == 0 {
+			err = fmt.Errorf("segwit transaction without inputs")
+			return
+		}
+		hasWitness := false
+		for i := range tx.Inputs {
+			itemCount, errCount := readCompactSize(reader)
+			if errCount != nil {
+				err = errCount
+				return
+			}
+			if itemCount > uint64(reader.Len()) {
+				err = fmt.Errorf("inputs[%d] witness item count %d exceeds the data", i, itemCount)
+				return
+			}
+			for j := uint64(0); j < itemCount; j++ {
+				item, errItem := readVarBytes(reader)
+				if errItem != nil {
+					err = fmt.Errorf("inputs[%d] witness[%d]: %w", i, j, errItem)
+					return
+				}
+				tx.Inputs[i].Witness = append(tx.Inputs[i].Witness, item)
+			}
+			hasWitness = hasWitness || itemCount > 0
+		}
+		if !hasWitness {
+			// bitcoind refuses the segwit serialization without any witness
+			err = fmt.Errorf("superfluous witness record")
+			return
+		}
+	}
+
+	tx.LockTime, err = readUint32(reader)
+	return
+}
+
+func readUint32(reader *bytes.Reader) (value uint32, err error) {
+	if err = binary.Read(reader, binary.LittleEndian, &value); err != nil {
+		err = io.ErrUnexpectedEOF
+	}
+	return
+}
+
+func readCompactSize(reader *bytes.Reader) (size uint64, err error) {
+
+	first, err := reader.ReadByte()
+	if err != nil {
+		err = io.ErrUnexpectedEOF
+		return
+	}
+
+	minimum := uint64(0)
+	switch first {
+	case 0xfd:
+		value := uint16(0)
+		err = binary.Read(reader, binary.LittleEndian, &value)
+		size, minimum = uint64(value), 0xfd
+	case 0xfe:
+		value := uint32(0)
+		err = binary.Read(reader, binary.LittleEndian, &value)
+		size, minimum = uint64(value), 0x10000
+	case 0xff:
+		err = binary.Read(reader, binary.LittleEndian, &size)
+		minimum = 0x100000000
+	default:
+		size = uint64(first)
+	}
+	if err != nil {
+		err = io.ErrUnexpectedEOF
+		return
+	}
+	if size < minimum {
+		err = fmt.Errorf("non-canonical compact size %d", size)
+	}
+	return
+}
+
+func readVarBytes(reader *bytes.Reader) (data []byte, err error) {
+
+	size, err := readCompactSize(reader)
+	if err != nil {
+		return
+	}
+	if size > uint64(reader.Len()) {
+		err = io.ErrUnexpectedEOF
+		return
+	}
+	data = make([]byte, size)
+	_, err = io.ReadFull(reader, data)
+	return
+}
+
+func writeCompactSize(buffer *bytes.Buffer, size uint64) {
+	switch {
+	case size < 0xfd:
+		buffer.WriteByte(byte(size))
+	case size <= 0xffff:
+		buffer.WriteByte(0xfd)
+		binary.Write(buffer, binary.LittleEndian, uint16(size))
+	case size <= 0xffffffff:
+		buffer.WriteByte(0xfe)
+		binary.Write(buffer, binary.LittleEndian, uint32(size))
+	default:
+		buffer.WriteByte(0xff)
+		binary.Write(buffer, binary.LittleEndian, size)
+	}
+}
+
+func writeVarBytes(buffer *bytes.Buffer, data []byte) {
+	writeCompactSize(buffer, uint64(len(data)))
+	buffer.Write(data)
+}
+
+func (tx Transaction) HasWitness() bool {
+	for _, input := range tx.Inputs {
+		if len(input.Witness) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (tx Transaction) serialize(withWitness bool) []byte {
+
+	withWitness = withWitness && tx.HasWitness()
+	buffer := &bytes.Buffer{}
+	binary.Write(buffer, binary.LittleEndian, tx.Version)
+	if withWitness {
+		buffer.Write([]byte{0x00, 0x01})
+	}
+
+	writeCompactSize(buffer, uint64(len(tx.Inputs)))
+	for _, input := range tx.Inputs {
+		buffer.Write(input.PrevTxID[:])
+		binary.Write(buffer, binary.LittleEndian, input.PrevVout)
+		writeVarBytes(buffer, input.ScriptSig)
+		binary.Write(buffer, binary.LittleEndian, input.Sequence)
+	}
+
+	writeCompactSize(buffer, uint64(len(tx.Outputs)))
+	for _, output := range tx.Outputs {
+		binary.Write(buffer, binary.LittleEndian, int64(output.Value))
+		writeVarBytes(buffer, output.ScriptPubKey)
+	}
+
+	if withWitness {
+		for _, input := range tx.Inputs {
+			writeCompactSize(buffer, uint64(len(input.Witness)))
+			for _, item := range input.Witness {
+				writeVarBytes(buffer, item)
+			}
+		}
+	}
+
+	binary.Write(buffer, binary.LittleEndian, tx.LockTime)
+	return buffer.Bytes()
+}
+
+// Serialize encodes tx with its witnesses (BIP144) when it has any.
+func (tx Transaction) Serialize() []byte {
+	return tx.serialize(true)
+}
+
+// SerializeNoWitness encodes tx in the legacy format, which its txid hashes.
+func (tx Transaction) SerializeNoWitness() []byte {
+	return tx.serialize(false)
+}
+
+func (tx Transaction) Hex() string {
+	return hex.EncodeToString(tx.Serialize())
+}
+
+func (tx Transaction) TxHash() Hash {
+	return hashOf(tx.SerializeNoWitness())
+}
+
+func (tx Transaction) WitnessHash() Hash {
+	return hashOf(tx.Serialize())
+}
+
+func (tx Transaction) TxID() string {
+	return tx.TxHash().String()
+}
+
+// WTxID is the "hash" of getrawtransaction: the txid for a transaction without witnesses.
+func (tx Transaction) WTxID() string {
+	return tx.WitnessHash().String()
+}
+
+// Size is the serialized size with witnesses, the "size" of getrawtransaction.
+func (tx Transaction) Size() int {
+	return len(tx.Serialize())
+}
+
+// BaseSize is the serialized size without witnesses.
+func (tx Transaction) BaseSize() int {
+	return len(tx.SerializeNoWitness())
+}
+
+func (tx Transaction) Weight() int {
+	return tx.BaseSize()*(witnessScaleFactor-1) + tx.Size()
+}
+
+// VSize is the virtual size fee rates are expressed in: weight / 4 rounded up.
+func (tx Transaction) VSize() int {
+	return (tx.Weight() + witnessScaleFactor - 1) / witnessScaleFactor
+}
+
+// OutputValue sums the values of the outputs.
+func (tx Transaction) OutputValue() (value Amount) {
+	for _, output := range tx.Outputs {
+		value += output.Value
+	}
+	return
+}
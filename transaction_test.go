@@ -0,0 +1,114 @@
+package gobitcoinclilight
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+func TestTransaction(t *testing.T) {
+
+	signedTx, err := DecodeTransactionHex(bitcoindtest.FixtureSignedRawTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signedTx.Hex() != bitcoindtest.FixtureSignedRawTx || !signedTx.HasWitness() {
+		t.Fatalf("round trip failed: %s", signedTx.Hex())
+	}
+	if signedTx.TxID() != bitcoindtest.FixtureTxID || signedTx.WTxID() != "7001cbcdf666a53dda45eff4540b53b17617644d127b0f023c901e20e8db71a5" {
+		t.Fatalf("unexpected txid %s, wtxid %s", signedTx.TxID(), signedTx.WTxID())
+	}
+	if signedTx.Size() != 384 || signedTx.Weight() != 888 || signedTx.VSize() != 222 {
+		t.Fatalf("unexpected size %d, weight %d, vsize %d", signedTx.Size(), signedTx.Weight(), signedTx.VSize())
+	}
+	if signedTx.Version != 2 || signedTx.LockTime != 0 || len(signedTx.Inputs) != 2 || len(signedTx.Outputs) != 2 {
+		t.Fatalf("unexpected transaction %+v", signedTx)
+	}
+	input := signedTx.Inputs[0]
+	if input.PrevTxID.String() != "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944" || input.PrevVout != 1 || input.Sequence != SequenceRBF || len(input.Witness) != 2 || len(input.Witness[1]) != 33 {
+		t.Fatalf("unexpected input %+v", input)
+	}
+	if signedTx.Outputs[1].Value != 24000 || hex.EncodeToString(signedTx.Outputs[1].ScriptPubKey) != "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c" || signedTx.OutputValue() != 24000 {
+		t.Fatalf("unexpected output %+v", signedTx.Outputs[1])
+	}
+
+	// the unsigned transaction is the legacy serialization with empty scriptSigs
+	unsignedTx, err := DecodeTransactionHex(bitcoindtest.FixtureRawTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unsignedTx.HasWitness() || unsignedTx.Hex() != bitcoindtest.FixtureRawTx || unsignedTx.TxID() != bitcoindtest.FixtureTxID || unsignedTx.WTxID() != bitcoindtest.FixtureTxID {
+		t.Fatalf("unexpected unsigned transaction %+v", unsignedTx)
+	}
+	if !bytes.Equal(signedTx.SerializeNoWitness(), unsignedTx.Serialize()) || unsignedTx.VSize() != unsignedTx.Size() {
+		t.Fatalf("the witness must not be part of the legacy serialization")
+	}
+
+	// without inputs, e.g. before fundrawtransaction: the output count 1 looks like the segwit flag
+	fundlessTx := Transaction{Version: 2, Outputs: unsignedTx.Outputs[1:]}
+	decodedTx, err := DecodeTransaction(fundlessTx.Serialize())
+	if err != nil || len(decodedTx.Inputs) != 0 || len(decodedTx.Outputs) != 1 || decodedTx.Outputs[0].Value != 24000 {
+		t.Fatalf("unexpected input-less transaction %+v: %v", decodedTx, err)
+	}
+
+	hash, err := NewHashFromString(bitcoindtest.FixtureTxID)
+	if err != nil || hash != signedTx.TxHash() {
+		t.Fatalf("unexpected hash %s: %v", hash, err)
+	}
+
+	incorrects := []string{
+		"",
+		bitcoindtest.FixtureRawTx[:len(bitcoindtest.FixtureRawTx)-2],
+		bitcoindtest.FixtureRawTx + "00",
+		"02000000fd0100",   // non-canonical input count
+		"0200000000010100", // truncated segwit transaction
+		"zz",
+	}
+	for _, incorrect := range incorrects {
+		if _, err = DecodeTransactionHex(incorrect); err == nil {
+			t.Fatalf("expected an error for %s", incorrect)
+		}
+	}
+}
+
+func TestCreatedRawTransactionVerify(t *testing.T) {
+
+	unsignedTx, err := DecodeTransactionHex(bitcoindtest.FixtureRawTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sequence := SequenceRBF
+	created := CreatedRawTransaction{
+		Hex: bitcoindtest.FixtureRawTx,
+		Inputs: []TxInput{
+			{TxID: "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944", Vout: 1, Sequence: &sequence},
+			{TxID: "9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455", Vout: 1},
+		},
+		Outputs: []TxOutput{
+			{Data: "48454c4c4f20696465616a6f6f2f676f2d626974636f696e2d636c692d6c69676874"},
+			{Address: bitcoindtest.FixtureAddress, Amount: 24000},
+		},
+	}
+	tx, err := created.Verify()
+	if err != nil || tx.TxID() != unsignedTx.TxID() {
+		t.Fatalf("unexpected verify %v", err)
+	}
+	created.Inputs[1].TxID = strings.ToUpper(created.Inputs[1].TxID)
+	if _, err = created.Verify(); err != nil {
+		t.Fatalf("unexpected verify of an uppercase txid %v", err)
+	}
+
+	created.Outputs[1].Amount = 23999
+	if _, err = created.Verify(); !errors.Is(err, ErrTransactionMismatch) {
+		t.Fatalf("expected ErrTransactionMismatch, got %v", err)
+	}
+	created.Outputs[1].Amount = 24000
+	created.Inputs[1].Vout = 0
+	if _, err = created.Verify(); !errors.Is(err, ErrTransactionMismatch) {
+		t.Fatalf("expected ErrTransactionMismatch, got %v", err)
+	}
+}
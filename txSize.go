@@ -0,0 +1,320 @@
+package gobitcoinclilight
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sizes of the dummy signatures of the estimates, as bitcoind's: an ECDSA
+// signature of the usual maximal size with its sighash byte, and a Schnorr
+// signature with the default sighash
+const (
+	ecdsaSignatureSize   = 72
+	schnorrSignatureSize = 64
+)
+
+// inputSpend is the scriptSig length and the witness item lengths of a signed input.
+type inputSpend struct {
+	scriptSigLength int
+	witnessItems    []int
+}
+
+func (spend inputSpend) weight() (weight int64, witness bool) {
+
+	weight = (32 + 4 + compactSizeLength(spend.scriptSigLength) + int64(spend.scriptSigLength) + 4) * witnessScaleFactor
+	if len(spend.witnessItems) == 0 {
+		return
+	}
+	weight += compactSizeLength(len(spend.witnessItems))
+	for _, item := range spend.witnessItems {
+		weight += compactSizeLength(item) + int64(item)
+	}
+	return weight, true
+}
+
+// scriptTypeSpend is the spend of a scriptPubKey of scriptType by a compressed
+// key. ScriptTypeP2SH stands for P2SH-P2WPKH, the only P2SH a key spends alone.
+func scriptTypeSpend(scriptType string) (spend inputSpend, err error) {
+
+	switch scriptType {
+	case ScriptTypeP2PKH:
+		spend.scriptSigLength = 1 + ecdsaSignatureSize + 1 + 33
+	case ScriptTypeP2PK:
+		spend.scriptSigLength = 1 + ecdsaSignatureSize
+	case ScriptTypeP2WPKH:
+		spend.witnessItems = []int{ecdsaSignatureSize, 33}
+	case ScriptTypeP2SH:
+		spend.scriptSigLength = 1 + 22
+		spend.witnessItems = []int{ecdsaSignatureSize, 33}
+	case ScriptTypeP2TR:
+		spend.witnessItems = []int{schnorrSignatureSize}
+	default:
+		err = fmt.Errorf("unknown input size of %s", scriptType)
+	}
+	return
+}
+
+// inputWeight is the weight of a signed input spending scriptType, see scriptTypeSpend.
+func inputWeight(scriptType string) (weight int64, witness bool, err error) {
+
+	spend, err := scriptTypeSpend(scriptType)
+	if err != nil {
+		return
+	}
+	weight, witness = spend.weight()
+	return
+}
+
+// unspentInputWeight is the weight of the signed input spending unspent, from
+// its descriptor, or from its scriptPubKey without a descriptor of the spend
+// (none, addr() or raw() of a watch-only output).
+func unspentInputWeight(unspent Unspent) (weight int64, witness bool, err error) {
+
+	spend, err := descriptorSpend(unspent.Desc)
+	if err != nil && (unspent.Desc == "" || strings.HasPrefix(unspent.Desc, "addr(") || strings.HasPrefix(unspent.Desc, "raw(")) {
+		scriptPubKey, errHex := hex.DecodeString(unspent.ScriptPubKey)
+		if errHex != nil {
+			err = fmt.Errorf("@hex.DecodeString(unspent.ScriptPubKey): %v", errHex)
+			return
+		}
+		if scriptType := ScriptTypeOf(scriptPubKey); scriptType == ScriptTypeP2SH {
+			// its redeem script may be anything
+			err = fmt.Errorf("unknown input size of %s without its descriptor", scriptType)
+		} else {
+			spend, err = scriptTypeSpend(scriptType)
+		}
+	}
+	if err != nil {
+		return
+	}
+	weight, witness = spend.weight()
+	return
+}
+
+// unwrapDescriptor returns the argument of the function name in descriptor,
+// e.g. "KEY" for name "wpkh" and descriptor "wpkh(KEY)".
+func unwrapDescriptor(descriptor string, name string) (argument string, ok bool) {
+	if !strings.HasPrefix(descriptor, name+"(") || !strings.HasSuffix(descriptor, ")") {
+		return
+	}
+	return descriptor[len(name)+1 : len(descriptor)-1], true
+}
+
+// descriptorKeyLength is the length of a key expression once serialized: an
+// uncompressed hex key is 65 bytes, an x-only one 32, any other 33.
+func descriptorKeyLength(key string) int {
+
+	if _, afterOrigin, found := strings.Cut(key, "]"); found {
+		key = afterOrigin
+	}
+	switch {
+	case len(key) == 130 && strings.HasPrefix(key, "04"):
+		return 65
+	case len(key) == 64:
+		return 32
+	}
+	return 33
+}
+
+// multisigScript returns the number of signatures and the length of the
+// script of a multi() or sortedmulti() descriptor.
+func multisigScript(descriptor string) (required int, scriptLength int, err error) {
+
+	argument, ok := unwrapDescriptor(descriptor, "multi")
+	if !ok {
+		argument, ok = unwrapDescriptor(descriptor, "sortedmulti")
+	}
+	if !ok {
+		err = fmt.Errorf("unsupported descriptor[%s]", descriptor)
+		return
+	}
+
+	arguments := strings.Split(argument, ",")
+	required, err = strconv.Atoi(arguments[0])
+	keys := arguments[1:]
+	if err != nil || required < 1 || required > len(keys) || len(keys) > 20 {
+		err = fmt.Errorf("incorrect multisig descriptor[%s]", descriptor)
+		return
+	}
+	// OP_k, the keys, OP_n and OP_CHECKMULTISIG
+	scriptLength = 3
+	for _, key := range keys {
+		scriptLength += 1 + descriptorKeyLength(key)
+	}
+	return
+}
+
+// pushLength is the length of the push of size bytes.
+func pushLength(size int) int {
+	switch {
+	case size < 76:
+		return 1 + size
+	case size <= 0xff:
+		return 2 + size
+	}
+	return 3 + size
+}
+
+// descriptorSpend is the spend of the output of descriptor, for pk, pkh, wpkh,
+// tr with a key path spend, sh(wpkh), and multisigs in sh, wsh or sh(wsh).
+func descriptorSpend(descriptor string) (spend inputSpend, err error) {
+
+	descriptor, _, _ = strings.Cut(descriptor, "#")
+
+	if _, ok := unwrapDescriptor(descriptor, "pk"); ok {
+		spend.scriptSigLength = 1 + ecdsaSignatureSize
+		return
+	}
+	if key, ok := unwrapDescriptor(descriptor, "pkh"); ok {
+		spend.scriptSigLength = 1 + ecdsaSignatureSize + 1 + descriptorKeyLength(key)
+		return
+	}
+	if key, ok := unwrapDescriptor(descriptor, "wpkh"); ok {
+		spend.witnessItems = []int{ecdsaSignatureSize, descriptorKeyLength(key)}
+		return
+	}
+	if key, ok := unwrapDescriptor(descriptor, "tr"); ok {
+		if strings.Contains(key, ",") {
+			err = fmt.Errorf("unknown input size of a script path spend of descriptor[%s]", descriptor)
+			return
+		}
+		spend.witnessItems = []int{schnorrSignatureSize}
+		return
+	}
+
+	witnessScript, nested := unwrapDescriptor(descriptor, "sh")
+	if !nested {
+		witnessScript = descriptor
+	}
+	if inner, ok := unwrapDescriptor(witnessScript, "wpkh"); ok && nested {
+		spend.scriptSigLength = 1 + 22
+		spend.witnessItems = []int{ecdsaSignatureSize, descriptorKeyLength(inner)}
+		return
+	}
+	if inner, ok := unwrapDescriptor(witnessScript, "wsh"); ok {
+		required, scriptLength, errScript := multisigScript(inner)
+		if errScript != nil {
+			err = errScript
+			return
+		}
+		if nested {
+			spend.scriptSigLength = 1 + 34
+		}
+		// the dummy element of OP_CHECKMULTISIG, the signatures and the script
+		spend.witnessItems = []int{0}
+		for i := 0; i < required; i++ {
+			spend.witnessItems = append(spend.witnessItems, ecdsaSignatureSize)
+		}
+		spend.witnessItems = append(spend.witnessItems, scriptLength)
+		return
+	}
+	if nested {
+		required, scriptLength, errScript := multisigScript(witnessScript)
+		if errScript != nil {
+			err = errScript
+			return
+		}
+		spend.scriptSigLength = 1 + required*(1+ecdsaSignatureSize) + pushLength(scriptLength)
+		return
+	}
+
+	err = fmt.Errorf("unsupported descriptor[%s]", descriptor)
+	return
+}
+
+func compactSizeLength(size int) int64 {
+	switch {
+	case size < 0xfd:
+		return 1
+	case size <= 0xffff:
+		return 3
+	}
+	return 5
+}
+
+func weightToVSize(weight int64) int64 {
+	return (weight + witnessScaleFactor - 1) / witnessScaleFactor
+}
+
+// SignedSizeEstimate predicts a transaction once its inputs are signed.
+type SignedSizeEstimate struct {
+	Weight      int64
+	VSize       int64
+	InputValue  Amount // of the spent outputs
+	OutputValue Amount
+}
+
+// Fee is the fee the transaction pays: its inputs minus its outputs.
+func (estimate SignedSizeEstimate) Fee() Amount {
+	return estimate.InputValue - estimate.OutputValue
+}
+
+// FeeRate is the fee rate the signed transaction will pay.
+func (estimate SignedSizeEstimate) FeeRate() FeeRate {
+	return FeeRateOf(estimate.Fee(), estimate.VSize)
+}
+
+// RequiredFee is the fee of the signed transaction at feeRate.
+func (estimate SignedSizeEstimate) RequiredFee(feeRate FeeRate) Amount {
+	return feeRate.Fee(estimate.VSize)
+}
+
+func EstimateSignedSizeHex(unsignedRawTx string, spent []Unspent) (estimate SignedSizeEstimate, err error) {
+
+	tx, err := DecodeTransactionHex(unsignedRawTx)
+	if err != nil {
+		err = fmt.Errorf("@DecodeTransactionHex(unsignedRawTx): %v", err)
+		return
+	}
+	return EstimateSignedSize(tx, spent)
+}
+
+// EstimateSignedSize predicts the size of tx once signed, e.g. the result of
+// CreateRawTransaction. spent holds the outputs its inputs spend, in any
+// order, as listunspent returns them: the size of each input comes from the
+// descriptor or else the scriptPubKey, with signatures of the maximal usual
+// size, so the estimate is at most a few vbytes over.
+func EstimateSignedSize(tx Transaction, spent []Unspent) (estimate SignedSizeEstimate, err error) {
+
+	unspents := make(map[string]Unspent, len(spent))
+	for _, unspent := range spent {
+		unspents[fmt.Sprintf("%s:%d", unspent.TxID, unspent.Vout)] = unspent
+	}
+
+	// the transaction without its scriptSigs and witnesses, then the signed inputs
+	stripped := tx
+	stripped.Inputs = make([]TransactionInput, len(tx.Inputs))
+	weight, witness, nonWitnessInputs := int64(0), false, int64(0)
+	for index, input := range tx.Inputs {
+		stripped.Inputs[index] = TransactionInput{PrevTxID: input.PrevTxID, PrevVout: input.PrevVout, Sequence: input.Sequence}
+		outpoint := fmt.Sprintf("%s:%d", input.PrevTxID, input.PrevVout)
+		unspent, found := unspents[outpoint]
+		if !found {
+			err = fmt.Errorf("inputs[%d]: spent output %s not given", index, outpoint)
+			return
+		}
+		inputWeight, inputWitness, errWeight := unspentInputWeight(unspent)
+		if errWeight != nil {
+			err = fmt.Errorf("inputs[%d]: %v", index, errWeight)
+			return
+		}
+		weight += inputWeight - (32+4+1+4)*witnessScaleFactor
+		witness = witness || inputWitness
+		if !inputWitness {
+			nonWitnessInputs++
+		}
+		estimate.InputValue += unspent.Amount
+	}
+
+	weight += int64(stripped.BaseSize()) * witnessScaleFactor
+	if witness {
+		// the segwit marker and flag, and an empty witness for each non witness input
+		weight += 2 + nonWitnessInputs
+	}
+	estimate.Weight = weight
+	estimate.VSize = weightToVSize(weight)
+	estimate.OutputValue = tx.OutputValue()
+	return
+}
@@ -0,0 +1,99 @@
+package gobitcoinclilight
+
+import (
+	"testing"
+
+	"github.com/ideajoo/go-bitcoin-cli-light/bitcoindtest"
+)
+
+func TestUnspentInputWeight(t *testing.T) {
+
+	const (
+		key             = "0307fb2416e1477f965dfee36f9525b0642759b22c23430ebe9a63124d62634b52"
+		keyWithOrigin   = "[3938a2e2/0h/0h/1h]" + key
+		uncompressedKey = "0450863ad64a87ae8a2fe83c1af1a8403cb53f53e486d8511dad8a04887e5b23522cd470243453a299fa9e77237716103abc11a1df38855ed6f2ee187e9c582ba6"
+		xpub            = "tpubD6NzVbkrYhZ4XgiXtGrdW5XDAPFCL9h7we1vwNCpn8tGbBcgfVYjXyhWo4E1xkh56hjod1RhGjxbaTLV3X4FyWuejifB9jusQ46QzG87VKp/0/*"
+		multisig        = "multi(2," + keyWithOrigin + "," + xpub + "," + key + ")"
+	)
+	for _, test := range []struct {
+		unspent Unspent
+		weight  int64
+		witness bool
+	}{
+		{Unspent{ScriptPubKey: "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac"}, 592, false},
+		{Unspent{Desc: "pkh(" + keyWithOrigin + ")#abcdefgh"}, 592, false},
+		{Unspent{Desc: "pkh(" + uncompressedKey + ")"}, 720, false},
+		{Unspent{Desc: "pk(" + key + ")"}, 456, false},
+		{Unspent{ScriptPubKey: "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c"}, 272, true},
+		{Unspent{Desc: "wpkh(" + xpub + ")"}, 272, true},
+		{Unspent{Desc: "sh(wpkh(" + key + "))"}, 364, true},
+		{Unspent{ScriptPubKey: "5120a60869f0dbcf1dc659c9cecbaf8050135ea9e8cdc487053f1dc6880949dc684c", Desc: "addr(bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr)"}, 230, true},
+		{Unspent{Desc: "tr(" + xpub + ")"}, 230, true},
+		// witness: 4 items, the dummy, 2 signatures and the 105 bytes script
+		{Unspent{Desc: "wsh(" + multisig + ")"}, 164 + 1 + 1 + 2*73 + 1 + 105, true},
+		{Unspent{Desc: "sh(wsh(sorted" + multisig + "))"}, 304 + 1 + 1 + 2*73 + 1 + 105, true},
+		// a scriptSig of 254 bytes, whose length takes 3 bytes
+		{Unspent{Desc: "sh(" + multisig + ")"}, (32 + 4 + 3 + 254 + 4) * 4, false},
+	} {
+		weight, witness, err := unspentInputWeight(test.unspent)
+		if err != nil || weight != test.weight || witness != test.witness {
+			t.Fatalf("%+v: unexpected weight %d, witness %v instead of %d: %v", test.unspent, weight, witness, test.weight, err)
+		}
+	}
+
+	for _, unspent := range []Unspent{
+		{ScriptPubKey: "a914b472a266d0bd89c13706a4132ccfb16f7c3b9fcb87"},
+		{ScriptPubKey: "00203938a2e285bff79dc6f96a8e9a96d54c6ce7586c3938a2e285bff79dc6f96a8e"},
+		{ScriptPubKey: "zz"},
+		{Desc: "tr(" + key + ",pk(" + key + "))"},
+		{Desc: "wsh(pk(" + key + "))"},
+		{Desc: "wsh(multi(3," + key + "," + key + "))"},
+		{Desc: "sh(sh(wpkh(" + key + ")))"},
+		{Desc: "combo(" + key + ")", ScriptPubKey: "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c"},
+	} {
+		if weight, _, err := unspentInputWeight(unspent); err == nil {
+			t.Fatalf("%+v: expected an error, got weight %d", unspent, weight)
+		}
+	}
+}
+
+func TestEstimateSignedSize(t *testing.T) {
+
+	// the inputs of the fixture transaction, from ListUnspentFixture
+	spent := []Unspent{
+		{TxID: "9879656fa7bbb23075ecb74db1faa24251c95098cf07d5fdb654ca0b01a5a455", Vout: 1, ScriptPubKey: "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c", Amount: 10000},
+		{TxID: "b0ea0b9f9bb6326bc4d339a71ea41f7592f0f9dd9ddcb7d6b14edcb6959d1944", Vout: 1, ScriptPubKey: "00143938a2e285bff79dc6f96a8e9a96d54c6ce7586c", Amount: 15000},
+	}
+	estimate, err := EstimateSignedSizeHex(bitcoindtest.FixtureRawTx, spent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signedTx, _ := DecodeTransactionHex(bitcoindtest.FixtureSignedRawTx)
+	// its signatures are 71 bytes, 1 less than estimated
+	if estimate.Weight != int64(signedTx.Weight())+2 || estimate.VSize != 223 || signedTx.VSize() != 222 {
+		t.Fatalf("unexpected estimate %+v for %d", estimate, signedTx.Weight())
+	}
+	if estimate.InputValue != 25000 || estimate.OutputValue != 24000 || estimate.Fee() != 1000 || estimate.FeeRate() != 4484 || estimate.RequiredFee(2000) != 446 {
+		t.Fatalf("unexpected estimate %+v", estimate)
+	}
+
+	// the signatures already there don't count
+	estimate, err = EstimateSignedSize(signedTx, spent)
+	if err != nil || estimate.VSize != 223 {
+		t.Fatalf("unexpected estimate %+v: %v", estimate, err)
+	}
+
+	// a P2PKH input makes the others carry an empty witness
+	spent[0].ScriptPubKey = "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac"
+	estimate, err = EstimateSignedSize(signedTx, spent)
+	if err != nil || estimate.Weight != int64(signedTx.Weight())+2-272+592+1 {
+		t.Fatalf("unexpected estimate %+v: %v", estimate, err)
+	}
+
+	if _, err = EstimateSignedSize(signedTx, spent[:1]); err == nil {
+		t.Fatalf("expected an error for a missing spent output")
+	}
+	if _, err = EstimateSignedSizeHex("00", spent); err == nil {
+		t.Fatalf("expected an error for an incorrect transaction")
+	}
+}